@@ -64,7 +64,7 @@ AFTER
 INSERT
     ON members BEGIN
 INSERT INTO
-    deadline(member, diff, kind, cause)
+    deadline_journal(member, diff, kind, cause)
 VALUES
     (
         new.id,
@@ -96,24 +96,173 @@ CREATE TABLE schedule (
     time_points TEXT NOT NULL
 );
 
+-- user can have several reservations of different roles, limit is set by ask config
 CREATE TABLE reservations (
-    vk_id INT PRIMARY KEY NOT NULL,
+    vk_id INT NOT NULL,
     role TEXT REFERENCES roles(name) NOT NULL,
     status TEXT AS (
         CASE
             WHEN is_confirmed = 0 THEN 'Under Consideration'
             ELSE CASE
                 WHEN greeting IS NULL THEN 'In Progress'
+                WHEN is_greeting_accepted = 0 THEN 'Under Review'
                 ELSE 'Done'
             END
         END
     ),
     -- id of vk message contained information
     introduction INT NOT NULL,
+    -- dialog with introduction if it is not dialog with user
+    -- (reservation is made by admin)
+    introduction_peer INT,
     is_confirmed INT NOT NULL DEFAULT 0,
     deadline DATETIME,
     -- json array for urls
     greeting TEXT,
+    -- greeting is accepted by admin (or review is turned off)
+    is_greeting_accepted INT NOT NULL DEFAULT 0,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (vk_id, role)
+);
+
+-- finished reservations
+CREATE TABLE reservations_archive (
+    -- alias to rowid
+    id INTEGER PRIMARY KEY NOT NULL,
+    vk_id INT NOT NULL,
+    role TEXT NOT NULL,
+    introduction INT NOT NULL,
+    introduction_peer INT,
+    is_confirmed INT NOT NULL,
+    deadline DATETIME,
+    greeting TEXT,
+    -- time reservation was made
+    created DATETIME NOT NULL,
+    outcome TEXT CHECK(
+        outcome IN (
+            'Expired',
+            'Cancelled',
+            'Declined',
+            'Accepted',
+            'Deleted',
+            'Lost'
+        )
+    ) NOT NULL,
+    reason TEXT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reservations_archive_vk_id ON reservations_archive(vk_id);
+
+-- every status reservation passed through, it is filled by triggers
+CREATE TABLE reservations_history (
+    -- alias to rowid
+    id INTEGER PRIMARY KEY NOT NULL,
+    vk_id INT NOT NULL,
+    role TEXT NOT NULL,
+    -- status of reservation or outcome of archived one
+    status TEXT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reservations_history_vk_id ON reservations_history(vk_id);
+
+CREATE TRIGGER reservations_history_insert
+AFTER
+INSERT
+    ON reservations BEGIN
+INSERT INTO
+    reservations_history(vk_id, role, status)
+VALUES
+    (new.vk_id, new.role, new.status);
+
+END;
+
+CREATE TRIGGER reservations_history_update
+AFTER
+UPDATE
+    ON reservations
+    WHEN new.status != old.status BEGIN
+INSERT INTO
+    reservations_history(vk_id, role, status)
+VALUES
+    (new.vk_id, new.role, new.status);
+
+END;
+
+CREATE TRIGGER reservations_history_archive
+AFTER
+INSERT
+    ON reservations_archive BEGIN
+INSERT INTO
+    reservations_history(vk_id, role, status)
+VALUES
+    (new.vk_id, new.role, new.outcome);
+
+END;
+
+-- images downloaded from vk, file in store is named by hash of content
+CREATE TABLE images (
+    url TEXT PRIMARY KEY NOT NULL,
+    hash TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_images_hash ON images(hash);
+
+-- reminders about reservation deadline which are already sent
+CREATE TABLE reservation_reminders (
+    vk_id INT NOT NULL,
+    role TEXT NOT NULL,
+    deadline DATETIME NOT NULL,
+    -- seconds before deadline
+    ahead INT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (vk_id, role, deadline, ahead)
+);
+
+-- requests of users to extend deadline of reservation in progress
+CREATE TABLE reservation_extensions (
+    -- alias to rowid
+    id INTEGER PRIMARY KEY NOT NULL,
+    vk_id INT NOT NULL,
+    role TEXT NOT NULL,
+    days INT NOT NULL,
+    reason TEXT NOT NULL,
+    status TEXT CHECK(status IN ('Pending', 'Approved', 'Declined')) NOT NULL DEFAULT 'Pending',
+    -- vk id of admin who decided
+    admin INT,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided DATETIME
+);
+
+CREATE INDEX idx_reservation_extensions_vk_id ON reservation_extensions(vk_id);
+
+-- users waiting for taken role, the first one is offered to claim role when it is free
+CREATE TABLE waitlist (
+    -- alias to rowid, order of queue
+    id INTEGER PRIMARY KEY NOT NULL,
+    vk_id INT NOT NULL,
+    role TEXT REFERENCES roles(name) NOT NULL,
+    -- role is held for user until offer deadline, null if role is not offered.
+    -- Unlike deadline of reservation it is real UTC, not shifted by timezone of ask,
+    -- because available_roles compares it with current time of database
+    offer_deadline DATETIME,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(vk_id, role)
+);
+
+CREATE INDEX idx_waitlist_role ON waitlist(role);
+
+-- users barred from reservations by admin, ban is permanent if until is null
+CREATE TABLE bans (
+    vk_id INT PRIMARY KEY NOT NULL,
+    reason TEXT NOT NULL,
+    until DATETIME,
+    -- vk id of admin who banned
+    admin INT NOT NULL,
     timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -123,6 +272,43 @@ CREATE TABLE ongoing_polls (
     timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
+-- poll status is not stored in reservations
+CREATE TRIGGER reservations_history_poll
+AFTER
+INSERT
+    ON ongoing_polls BEGIN
+INSERT INTO
+    reservations_history(vk_id, role, status)
+SELECT
+    vk_id,
+    role,
+    'Poll'
+FROM
+    reservations
+WHERE
+    role = new.role;
+
+END;
+
+-- how ended polls are decided by rules of config, archive of ended polls
+CREATE TABLE poll_decisions (
+    -- alias to rowid
+    id INTEGER PRIMARY KEY NOT NULL,
+    role TEXT NOT NULL,
+    post INT NOT NULL,
+    -- comma separated vk ids
+    participants TEXT NOT NULL DEFAULT '',
+    kind TEXT CHECK(
+        kind IN ('Winner', 'NoOne', 'NoQuorum', 'Tie', 'Runoff', 'Withdrawn')
+    ) NOT NULL,
+    winner INT,
+    -- json array of votes by answer
+    results TEXT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_poll_decisions_role ON poll_decisions(role);
+
 CREATE TABLE poll_answer_cache (
     poll_id INT,
     answer_id INT,
@@ -130,13 +316,35 @@ CREATE TABLE poll_answer_cache (
     PRIMARY KEY (poll_id, answer_id)
 );
 
+CREATE TABLE audit_log (
+    -- alias to rowid
+    id INTEGER PRIMARY KEY NOT NULL,
+    actor_kind TEXT CHECK(actor_kind IN ('Admin', 'User', 'Watcher')) NOT NULL,
+    -- vk id of admin or user, null for watcher
+    actor INT,
+    action TEXT NOT NULL,
+    -- changed entity
+    vk_id INT,
+    role TEXT,
+    -- json of entity before and after change
+    before TEXT,
+    after TEXT,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_vk_id ON audit_log(vk_id);
+
+CREATE INDEX idx_audit_log_role ON audit_log(role);
+
 -- views
 CREATE VIEW reservations_details AS
 SELECT
     reservations.vk_id,
     reservations.introduction,
+    reservations.introduction_peer,
     reservations.deadline,
     reservations.greeting,
+    reservations.timestamp,
     CASE
         WHEN ongoing_polls.post IS NOT NULL THEN 'Poll'
         ELSE reservations.status
@@ -148,17 +356,33 @@ FROM
     INNER JOIN roles ON reservations.role = roles.name
     LEFT JOIN ongoing_polls USING(role);
 
+CREATE VIEW reservations_history_details AS
+SELECT
+    reservations_history.*,
+    roles.[group],
+    roles_groups.shown_name AS group_shown_name
+FROM
+    reservations_history
+    LEFT JOIN roles ON reservations_history.role = roles.name
+    LEFT JOIN roles_groups ON roles.[group] = roles_groups.name;
+
+-- participants are ordered by vk_id through ordered subquery
 CREATE VIEW polls AS
 SELECT
     reservations.role,
     count(*) as count,
-    group_concat(vk_id) OVER (
-        ORDER BY
-            vk_id
-    ) AS participants,
+    group_concat(vk_id) AS participants,
     json_group_object(cast(vk_id as text), json(greeting)) AS greetings
 FROM
-    reservations
+    (
+        SELECT
+            *
+        FROM
+            reservations
+        ORDER BY
+            role,
+            vk_id
+    ) AS reservations
 WHERE
     NOT EXISTS(
         SELECT
@@ -213,8 +437,53 @@ WHERE
             role
         FROM
             members
+        UNION
+        SELECT
+            role
+        FROM
+            waitlist
+        WHERE
+            unixepoch(offer_deadline) > unixepoch('now')
     );
 
+-- role is waited for if it is reserved or is not available
+CREATE VIEW waitlist_roles AS
+SELECT
+    *
+FROM
+    roles
+WHERE
+    name NOT IN (
+        SELECT
+            role
+        FROM
+            members
+    )
+    AND (
+        name IN (
+            SELECT
+                role
+            FROM
+                reservations
+        )
+        OR name NOT IN (
+            SELECT
+                name
+            FROM
+                available_roles
+        )
+    );
+
+CREATE VIEW waitlist_details AS
+SELECT
+    waitlist.id,
+    waitlist.vk_id,
+    waitlist.offer_deadline,
+    roles.*
+FROM
+    waitlist
+    INNER JOIN roles ON waitlist.role = roles.name;
+
 CREATE VIEW deadlines AS
 SELECT
     member,
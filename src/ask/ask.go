@@ -2,15 +2,22 @@ package ask
 
 import (
 	"ask-bot/src/ask/db"
+	"ask-bot/src/ask/images"
+	"context"
+	"errors"
 	"time"
 
 	"github.com/hori-ryota/zaperr"
 	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
 )
 
 type Ask struct {
-	config *Config
-	db     *db.DB
+	config  *Config
+	db      *db.DB
+	storage Storage
+	backup  *db.BackupConfig
+	images  *images.Store
 
 	timezone time.Duration
 }
@@ -24,16 +31,119 @@ func New(config *Config) *Ask {
 	}
 }
 
-func (a *Ask) Init(path string, schema string, allow_deletion bool) error {
+// storage is already initialized, no database is used
+func NewWithStorage(config *Config, storage Storage) *Ask {
+	a := New(config)
+	a.storage = storage
+
+	return a
+}
+
+// WithTx runs fn with Ask which storage is bound to one transaction.
+// Transaction is rolled back if fn fails, nested WithTx joins the outer one.
+func (a *Ask) WithTx(ctx context.Context, fn func(tx *Ask) error) error {
+	return a.storage.Transaction(ctx, func(s Storage) error {
+		tx := *a
+		tx.storage = s
+
+		return fn(&tx)
+	})
+}
+
+// backup is optional, database is saved before migration
+func (a *Ask) Init(path string, schema string, allow_deletion bool, backup *db.BackupConfig) error {
 	d, err := db.NewDB(path)
 	if err != nil {
 		return zaperr.Wrap(err, "failed to create new db")
 	}
-	if err = d.Init(schema, allow_deletion); err != nil {
+
+	a.db = d
+	a.storage = NewSQLite(d)
+	a.backup = backup
+
+	err = a.Backup(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if err = d.Init(schema, allow_deletion, migrations); err != nil {
 		return zaperr.Wrap(err, "failed to create new db")
 	}
 
-	a.db = d
+	// refuse to work with queries which do not fit schema
+	return CheckQueries(d)
+}
+
+func (a *Ask) Backup(ctx context.Context) error {
+	if a.backup == nil || a.db == nil {
+		return nil
+	}
+
+	filename, err := a.db.Backup(ctx, a.backup.Dir)
+	if err != nil {
+		return err
+	}
+	if len(filename) == 0 {
+		return nil
+	}
+
+	zap.S().Infow("database snapshot is saved",
+		"filename", filename)
+
+	return db.PruneBackups(a.backup)
+}
+
+// database and schema are only read, nothing is applied
+func PlanMigration(path string, schema string, allow_deletion bool) (*db.MigrationPlan, error) {
+	d, err := db.NewReadOnlyDB(path)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to open db read only")
+	}
+	defer d.Close()
+
+	return d.PlanFromFile(schema, allow_deletion, migrations)
+}
+
+// Restore swaps database content with snapshot. Snapshot should be intact
+// and fit schema without losing data, current database is saved before.
+func Restore(snapshot string, path string, schema string, backup *db.BackupConfig) error {
+	s, err := db.NewReadOnlyDB(snapshot)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to open snapshot read only")
+	}
+	defer s.Close()
+
+	err = s.CheckIntegrity()
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.PlanFromFile(schema, true, migrations)
+	if err != nil {
+		return err
+	}
+	if plan.LosesData() {
+		err := errors.New("snapshot does not fit schema")
+		return zaperr.Wrap(err, "",
+			zap.String("snapshot", snapshot),
+			zap.Any("plan", plan))
+	}
+
+	d, err := db.NewDB(path)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to create new db")
+	}
+	defer d.Close()
+
+	if backup != nil {
+		filename, err := d.Backup(context.Background(), backup.Dir)
+		if err != nil {
+			return err
+		}
+
+		zap.S().Infow("database snapshot is saved before restore",
+			"filename", filename)
+	}
 
-	return nil
+	return d.Restore(s)
 }
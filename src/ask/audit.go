@@ -0,0 +1,197 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+type ActorKind string
+
+var ActorKinds = struct {
+	Admin   ActorKind
+	User    ActorKind
+	Watcher ActorKind
+}{
+	Admin:   "Admin",
+	User:    "User",
+	Watcher: "Watcher",
+}
+
+func (k ActorKind) Value() (driver.Value, error) {
+	return string(k), nil
+}
+
+func (k *ActorKind) Scan(value interface{}) error {
+	if value == nil {
+		return errors.New("ActorKind is not nullable")
+	}
+	if str, err := driver.String.ConvertValue(value); err == nil {
+		if v, ok := str.(string); ok {
+			// check if is valid
+			if v != string(ActorKinds.Admin) &&
+				v != string(ActorKinds.User) &&
+				v != string(ActorKinds.Watcher) {
+				return errors.New("value is not valid ActorKind value")
+			}
+			*k = ActorKind(v)
+			return nil
+		}
+	}
+	return errors.New("failed to scan ActorKind")
+}
+
+// who changes state of ask
+type Actor struct {
+	Kind ActorKind
+	VkID int
+}
+
+func AdminActor(vk_id int) Actor {
+	return Actor{ActorKinds.Admin, vk_id}
+}
+
+func UserActor(vk_id int) Actor {
+	return Actor{ActorKinds.User, vk_id}
+}
+
+var WatcherActor = Actor{Kind: ActorKinds.Watcher}
+
+type AuditAction string
+
+var AuditActions = struct {
+	AddReservation      AuditAction
+	ConfirmReservation  AuditAction
+	DeclineReservation  AuditAction
+	CompleteReservation AuditAction
+	AcceptGreeting      AuditAction
+	RejectGreeting      AuditAction
+	DeleteReservation   AuditAction
+	AddMember           AuditAction
+	ChangeDeadline      AuditAction
+	ChangeAlbum         AuditAction
+	ChangeBoard         AuditAction
+	AddOngoingPoll      AuditAction
+	EndPoll             AuditAction
+	DropPoll            AuditAction
+	JoinWaitlist        AuditAction
+	LeaveWaitlist       AuditAction
+	OfferWaitlist       AuditAction
+	RequestExtension    AuditAction
+	ApproveExtension    AuditAction
+	DeclineExtension    AuditAction
+	Ban                 AuditAction
+	LiftBan             AuditAction
+}{
+	AddReservation:      "AddReservation",
+	ConfirmReservation:  "ConfirmReservation",
+	DeclineReservation:  "DeclineReservation",
+	CompleteReservation: "CompleteReservation",
+	AcceptGreeting:      "AcceptGreeting",
+	RejectGreeting:      "RejectGreeting",
+	DeleteReservation:   "DeleteReservation",
+	AddMember:           "AddMember",
+	ChangeDeadline:      "ChangeDeadline",
+	ChangeAlbum:         "ChangeAlbum",
+	ChangeBoard:         "ChangeBoard",
+	AddOngoingPoll:      "AddOngoingPoll",
+	EndPoll:             "EndPoll",
+	DropPoll:            "DropPoll",
+	JoinWaitlist:        "JoinWaitlist",
+	LeaveWaitlist:       "LeaveWaitlist",
+	OfferWaitlist:       "OfferWaitlist",
+	RequestExtension:    "RequestExtension",
+	ApproveExtension:    "ApproveExtension",
+	DeclineExtension:    "DeclineExtension",
+	Ban:                 "Ban",
+	LiftBan:             "LiftBan",
+}
+
+type AuditRecord struct {
+	Id        int            `db:"id"`
+	ActorKind ActorKind      `db:"actor_kind"`
+	Actor     sql.NullInt32  `db:"actor"`
+	Action    AuditAction    `db:"action"`
+	VkID      sql.NullInt32  `db:"vk_id"`
+	Role      sql.NullString `db:"role"`
+	Before    sql.NullString `db:"before"`
+	After     sql.NullString `db:"after"`
+	Timestamp time.Time      `db:"timestamp"`
+}
+
+func (a *Ask) AuditByVkID(ctx context.Context, vk_id int, limit int) ([]AuditRecord, error) {
+	records, err := a.storage.AuditByVkID(ctx, vk_id, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		records[i].Timestamp = records[i].Timestamp.Add(a.timezone)
+	}
+
+	return records, nil
+}
+
+func (a *Ask) AuditByRole(ctx context.Context, role string, limit int) ([]AuditRecord, error) {
+	records, err := a.storage.AuditByRole(ctx, role, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		records[i].Timestamp = records[i].Timestamp.Add(a.timezone)
+	}
+
+	return records, nil
+}
+
+// before and after are saved as json, nil means entity does not exist.
+// Zero vk id and empty role are not saved.
+func (a *Ask) audit(ctx context.Context, actor Actor, action AuditAction, vk_id int, role string, before interface{}, after interface{}) error {
+	record := AuditRecord{
+		ActorKind: actor.Kind,
+		Actor:     sql.NullInt32{Int32: int32(actor.VkID), Valid: actor.VkID != 0},
+		Action:    action,
+		VkID:      sql.NullInt32{Int32: int32(vk_id), Valid: vk_id != 0},
+		Role:      sql.NullString{String: role, Valid: len(role) > 0},
+	}
+
+	var err error
+
+	record.Before, err = auditJSON(before)
+	if err != nil {
+		return err
+	}
+	record.After, err = auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	return a.storage.AddAuditRecord(ctx, record)
+}
+
+func auditJSON(entity interface{}) (sql.NullString, error) {
+	if entity == nil {
+		return sql.NullString{}, nil
+	}
+
+	value := reflect.ValueOf(entity)
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		return sql.NullString{}, nil
+	}
+
+	content, err := json.Marshal(entity)
+	if err != nil {
+		return sql.NullString{}, zaperr.Wrap(err, "failed to marshal audit entity",
+			zap.Any("entity", entity))
+	}
+
+	return sql.NullString{String: string(content), Valid: true}, nil
+}
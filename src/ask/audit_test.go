@@ -0,0 +1,75 @@
+package ask
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.DeclineReservation(ctx, AdminActor(1), 10, "alice", "no greeting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.ChangeAlbums(ctx, WatcherActor, map[string]int{"alice": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := a.AuditByVkID(ctx, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("wrong audit records: %v", records)
+	}
+
+	declined := records[0]
+	if declined.Action != AuditActions.DeclineReservation ||
+		declined.ActorKind != ActorKinds.Admin ||
+		declined.Actor.Int32 != 1 ||
+		declined.Role.String != "alice" ||
+		declined.After.Valid {
+		t.Errorf("wrong decline record: %v", declined)
+	}
+
+	var before Reservation
+	err = json.Unmarshal([]byte(declined.Before.String), &before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.VkID != 10 || before.Status != ReservationStatuses.UnderConsideration {
+		t.Errorf("wrong reservation before decline: %v", before)
+	}
+
+	added := records[1]
+	if added.Action != AuditActions.AddReservation ||
+		added.ActorKind != ActorKinds.User ||
+		added.Before.Valid ||
+		!added.After.Valid {
+		t.Errorf("wrong add record: %v", added)
+	}
+
+	records, err = a.AuditByRole(ctx, "alice", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 ||
+		records[0].Action != AuditActions.ChangeAlbum ||
+		records[0].ActorKind != ActorKinds.Watcher ||
+		records[0].Actor.Valid {
+		t.Errorf("wrong audit records by role: %v", records)
+	}
+}
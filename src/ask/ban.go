@@ -0,0 +1,122 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+// user is barred from making reservations
+type Ban struct {
+	VkID   int    `db:"vk_id"`
+	Reason string `db:"reason"`
+	// permanent ban if null
+	Until     sql.NullTime `db:"until"`
+	Admin     int          `db:"admin"`
+	Timestamp time.Time    `db:"timestamp"`
+}
+
+func (b *Ban) Permanent() bool {
+	return !b.Until.Valid
+}
+
+// active ban of user, nil if user is not banned
+func (a *Ask) Ban(ctx context.Context, vk_id int) (*Ban, error) {
+	ban, err := a.storage.Ban(ctx, vk_id, time.Now().UTC())
+	if err != nil || ban == nil {
+		return nil, err
+	}
+
+	ban.Until.Time = ban.Until.Time.Add(a.timezone)
+	ban.Timestamp = ban.Timestamp.Add(a.timezone)
+	return ban, nil
+}
+
+func (a *Ask) Bans(ctx context.Context) ([]Ban, error) {
+	bans, err := a.storage.Bans(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range bans {
+		bans[i].Until.Time = bans[i].Until.Time.Add(a.timezone)
+		bans[i].Timestamp = bans[i].Timestamp.Add(a.timezone)
+	}
+
+	return bans, nil
+}
+
+// ban is permanent if days is zero, previous ban of user is replaced
+func (a *Ask) BanUser(ctx context.Context, actor Actor, vk_id int, reason string, days int) error {
+	if days < 0 {
+		err := errors.New("ban days should not be negative")
+		return zaperr.Wrap(err, "",
+			zap.Int("vk_id", vk_id),
+			zap.Int("days", days))
+	}
+
+	var until sql.NullTime
+	if days > 0 {
+		until = sql.NullTime{
+			Time:  time.Now().UTC().Add(time.Duration(days) * 24 * time.Hour),
+			Valid: true,
+		}
+	}
+
+	return a.WithTx(ctx, func(tx *Ask) error {
+		now := time.Now().UTC()
+
+		before, err := tx.storage.Ban(ctx, vk_id, now)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.AddBan(ctx, vk_id, reason, until, actor.VkID)
+		if err != nil {
+			return err
+		}
+
+		after, err := tx.storage.Ban(ctx, vk_id, now)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.Ban, vk_id, "", before, after)
+	})
+}
+
+func (a *Ask) LiftBan(ctx context.Context, actor Actor, vk_id int) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.storage.Ban(ctx, vk_id, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DeleteBan(ctx, vk_id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.LiftBan, vk_id, "", before, nil)
+	})
+}
+
+func (a *Ask) checkBan(ctx context.Context, vk_id int) error {
+	ban, err := a.storage.Ban(ctx, vk_id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	if ban != nil {
+		err := errors.New("user is banned")
+		return zaperr.Wrap(err, "",
+			zap.Int("vk_id", vk_id),
+			zap.Any("ban", ban))
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package ask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBannedUserCannotReserve(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration: 24 * time.Hour,
+		MaxReservations:     2,
+	}, sqliteStorage(t, fixture))
+
+	err := a.BanUser(ctx, AdminActor(1), 10, "no-show", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ban, err := a.Ban(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ban == nil || ban.Permanent() || ban.Reason != "no-show" {
+		t.Fatalf("wrong ban: %v", ban)
+	}
+
+	err = a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err == nil {
+		t.Error("banned user should not reserve")
+	}
+
+	// admin is not bound by ban
+	_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 10, "bob", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.LiftBan(ctx, AdminActor(1), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := a.AuditByVkID(ctx, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := map[AuditAction]bool{}
+	for _, record := range records {
+		actions[record.Action] = true
+	}
+	if !actions[AuditActions.Ban] || !actions[AuditActions.LiftBan] {
+		t.Errorf("ban should be audited: %v", records)
+	}
+}
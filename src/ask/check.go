@@ -0,0 +1,161 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+type storageCall struct {
+	method string
+	call   func() error
+}
+
+// every Storage method with sample arguments, new methods should be added here.
+// Arguments are not empty, because statements depend on them.
+func storageCalls(ctx context.Context, s Storage) []storageCall {
+	now := time.Now()
+
+	ignore := func(_ interface{}, err error) error { return err }
+
+	return []storageCall{
+		{"Transaction", func() error {
+			return s.Transaction(ctx, func(Storage) error { return nil })
+		}},
+
+		{"IsAdmin", func() error { return ignore(s.IsAdmin(ctx, 0)) }},
+
+		{"Roles", func() error { return ignore(s.Roles(ctx)) }},
+		{"AvailableRoles", func() error { return ignore(s.AvailableRoles(ctx)) }},
+		{"RolesStartWith", func() error { return ignore(s.RolesStartWith(ctx, "")) }},
+		{"AvailableRolesStartWith", func() error { return ignore(s.AvailableRolesStartWith(ctx, "")) }},
+		{"Role", func() error { return ignore(s.Role(ctx, "")) }},
+		{"RolesDictionary", func() error { return ignore(s.RolesDictionary(ctx)) }},
+		{"MatchHashtags", func() error { return ignore(s.MatchHashtags(ctx, []string{""})) }},
+		{"ChangeAlbums", func() error { return s.ChangeAlbums(ctx, map[string]int{"": 0}) }},
+		{"ChangeBoards", func() error { return s.ChangeBoards(ctx, map[string]int{"": 0}) }},
+
+		{"AddReservation", func() error { return s.AddReservation(ctx, 0, "", 0, true) }},
+		{"AddConfirmedReservation", func() error { return s.AddConfirmedReservation(ctx, 0, "", 1, 0, now) }},
+		{"Reservation", func() error { return ignore(s.Reservation(ctx, 0, "")) }},
+		{"ReservationsByVkID", func() error { return ignore(s.ReservationsByVkID(ctx, 0)) }},
+		{"ReservationsByStatus", func() error { return ignore(s.ReservationsByStatus(ctx, ReservationStatuses.InProgress)) }},
+		{"ReservationsByRole", func() error { return ignore(s.ReservationsByRole(ctx, "")) }},
+		{"Reservations", func() error { return ignore(s.Reservations(ctx)) }},
+		{"ConfirmReservation", func() error { return s.ConfirmReservation(ctx, 0, "", now) }},
+		{"CompleteReservation", func() error { return s.CompleteReservation(ctx, 0, "", Urls{}, true) }},
+		{"AcceptGreeting", func() error { return s.AcceptGreeting(ctx, 0, "") }},
+		{"RejectGreeting", func() error { return s.RejectGreeting(ctx, 0, "") }},
+		{"DeleteReservation", func() error { return s.DeleteReservation(ctx, 0, "", ReservationOutcomes.Deleted, "") }},
+		{"DeleteReservationByDeadline", func() error { return s.DeleteReservationByDeadline(ctx, now, "") }},
+		{"DeleteReservationByRole", func() error { return s.DeleteReservationByRole(ctx, "", ReservationOutcomes.Accepted, "") }},
+		{"ArchivedReservations", func() error { return ignore(s.ArchivedReservations(ctx, 0)) }},
+		{"ChangeReservationDeadlineByRole", func() error { return s.ChangeReservationDeadlineByRole(ctx, "", now) }},
+		{"AddReservationReminder", func() error { return ignore(s.AddReservationReminder(ctx, 0, "", now, time.Hour)) }},
+		{"ReservationsHistory", func() error { return ignore(s.ReservationsHistory(ctx)) }},
+
+		{"AddImage", func() error { return s.AddImage(ctx, Image{}) }},
+		{"ImageByUrl", func() error { return ignore(s.ImageByUrl(ctx, "")) }},
+
+		{"AddReservationExtension", func() error { return s.AddReservationExtension(ctx, 0, "", 1, "") }},
+		{"ReservationExtension", func() error { return ignore(s.ReservationExtension(ctx, 0)) }},
+		{"ReservationExtensionsByRole", func() error { return ignore(s.ReservationExtensionsByRole(ctx, "")) }},
+		{"ReservationExtensionsByStatus", func() error { return ignore(s.ReservationExtensionsByStatus(ctx, ExtensionStatuses.Pending)) }},
+		{"DecideReservationExtension", func() error { return s.DecideReservationExtension(ctx, 0, ExtensionStatuses.Approved, 0) }},
+
+		{"WaitlistRoles", func() error { return ignore(s.WaitlistRoles(ctx)) }},
+		{"WaitlistByVkID", func() error { return ignore(s.WaitlistByVkID(ctx, 0)) }},
+		{"JoinWaitlist", func() error { return s.JoinWaitlist(ctx, 0, "") }},
+		{"LeaveWaitlist", func() error { return s.LeaveWaitlist(ctx, 0, "") }},
+		{"WaitlistToOffer", func() error { return ignore(s.WaitlistToOffer(ctx)) }},
+		{"OfferWaitlist", func() error { return s.OfferWaitlist(ctx, 0, now) }},
+		{"ExpiredWaitlistOffers", func() error { return ignore(s.ExpiredWaitlistOffers(ctx, now)) }},
+
+		{"AddBan", func() error { return s.AddBan(ctx, 0, "", sql.NullTime{}, 0) }},
+		{"Ban", func() error { return ignore(s.Ban(ctx, 0, now)) }},
+		{"Bans", func() error { return ignore(s.Bans(ctx, now)) }},
+		{"DeleteBan", func() error { return s.DeleteBan(ctx, 0) }},
+
+		{"Member", func() error { return ignore(s.Member(ctx, 0)) }},
+		{"MemberByRole", func() error { return ignore(s.MemberByRole(ctx, "")) }},
+		{"MembersByVkID", func() error { return ignore(s.MembersByVkID(ctx, 0)) }},
+		{"AddMember", func() error { return ignore(s.AddMember(ctx, 0, "")) }},
+
+		{"Deadline", func() error { return ignore(s.Deadline(ctx, 0)) }},
+		{"DeadlineJournal", func() error { return ignore(s.DeadlineJournal(ctx, 0)) }},
+		{"ChangeDeadline", func() error { return s.ChangeDeadline(ctx, 0, 0, DeadlineCauses.Other, "") }},
+
+		{"PointsByVkID", func() error { return ignore(s.PointsByVkID(ctx, 0)) }},
+		{"HistoryPointsByVkID", func() error { return ignore(s.HistoryPointsByVkID(ctx, 0)) }},
+
+		{"OngoingPolls", func() error { return ignore(s.OngoingPolls(ctx)) }},
+		{"AddOngoingPoll", func() error { return s.AddOngoingPoll(ctx, "", 0) }},
+		{"DeleteOngoingPoll", func() error { return s.DeleteOngoingPoll(ctx, "") }},
+		{"AddPollDecision", func() error {
+			return s.AddPollDecision(ctx, PollDecision{Kind: PollDecisionKinds.Tie, Results: PollResults{}})
+		}},
+		{"LastPollDecision", func() error { return ignore(s.LastPollDecision(ctx, "")) }},
+		{"PollDecisions", func() error { return ignore(s.PollDecisions(ctx, 1)) }},
+		{"PollDecisionsByRole", func() error { return ignore(s.PollDecisionsByRole(ctx, "", 1)) }},
+		{"PollDecisionsRoles", func() error { return ignore(s.PollDecisionsRoles(ctx, "")) }},
+		{"Polls", func() error { return ignore(s.Polls(ctx)) }},
+		{"PendingPolls", func() error { return ignore(s.PendingPolls(ctx)) }},
+		{"SavePollAnswers", func() error { return s.SavePollAnswers(ctx, 0, []PollAnswer{{}}) }},
+		{"LoadPollAnswer", func() error {
+			_, _, err := s.LoadPollAnswer(ctx, 0, 0)
+			return err
+		}},
+		{"DeletePollAnswers", func() error { return s.DeletePollAnswers(ctx, 0) }},
+
+		{"Timeslots", func() error { return ignore(s.Timeslots(ctx, TimeslotKinds.Polls)) }},
+
+		{"AddAuditRecord", func() error { return s.AddAuditRecord(ctx, AuditRecord{ActorKind: ActorKinds.Watcher}) }},
+		{"AuditByVkID", func() error { return ignore(s.AuditByVkID(ctx, 0, 1)) }},
+		{"AuditByRole", func() error { return ignore(s.AuditByRole(ctx, "", 1)) }},
+	}
+}
+
+// CheckQueries prepares every statement of SQLite storage against database
+// without executing them.
+func CheckQueries(d *db.DB) error {
+	checker := d.Checker()
+	s := NewSQLite(checker)
+
+	// statements are only prepared
+	for _, c := range storageCalls(context.Background(), s) {
+		err := c.call()
+		if err != nil {
+			return zaperr.Wrap(err, "failed to check storage method",
+				zap.String("method", c.method))
+		}
+	}
+
+	return checker.Err()
+}
+
+// Check migrates in-memory copy of database and checks queries against it,
+// database itself is not changed.
+func Check(path string, schema string, allow_deletion bool) error {
+	d, err := db.NewReadOnlyDB(path)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to open db read only")
+	}
+	defer d.Close()
+
+	copied, err := d.Copy()
+	if err != nil {
+		return err
+	}
+	defer copied.Close()
+
+	err = copied.Init(schema, allow_deletion, migrations)
+	if err != nil {
+		return err
+	}
+
+	return CheckQueries(copied)
+}
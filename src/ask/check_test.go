@@ -0,0 +1,46 @@
+package ask
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCheckCoversStorage(t *testing.T) {
+	var methods []string
+
+	storage := reflect.TypeOf((*Storage)(nil)).Elem()
+	for i := 0; i < storage.NumMethod(); i++ {
+		methods = append(methods, storage.Method(i).Name)
+	}
+
+	var checked []string
+	for _, c := range storageCalls(context.Background(), nil) {
+		checked = append(checked, c.method)
+	}
+	slices.Sort(checked)
+
+	if !slices.Equal(methods, checked) {
+		t.Errorf("checked methods differ from storage:\n%v\n%v", methods, checked)
+	}
+}
+
+func TestCheckQueries(t *testing.T) {
+	d := memoryDB(t)
+
+	err := CheckQueries(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Exec(context.Background(), "DROP VIEW pending_polls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = CheckQueries(d)
+	if err == nil {
+		t.Error("missing view should fail check")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	str2duration "github.com/xhit/go-str2duration/v2"
@@ -17,12 +18,50 @@ type OrganizationHashtags struct {
 	LeavingHashtag    string `json:"ASK_LEAVING_HASHTAG"`
 }
 
+// rules to decide ended polls
+type PollRules struct {
+	// minimum of total votes, poll without quorum has no winner
+	Quorum int `json:"ASK_POLL_QUORUM"`
+	// winner should lead the next answer by this number of votes, zero is the same as one
+	MinLead int `json:"ASK_POLL_MIN_LEAD"`
+	// what happens if "no one" answer wins
+	NoOne PollNoOneRule `json:"ASK_POLL_NO_ONE"`
+	// tied candidates get runoff poll in the next slot, tie of runoff has no winner
+	Runoff bool `json:"ASK_POLL_RUNOFF"`
+}
+
 type Config struct {
 	Timezone             int           `json:"ASK_TIMEZONE"`
 	Deadline             time.Duration `json:"ASK_DEADLINE"`
 	ReservationDuration  time.Duration `json:"ASK_RESERVATION_DURATION"`
 	NoConfirmReservation bool          `json:"ASK_NO_CONFIRM_RESERVATION"`
 
+	// how many reservations of different roles user can have at once
+	MaxReservations int `json:"ASK_MAX_RESERVATIONS"`
+
+	// greetings are reviewed by admin before poll
+	ReviewGreetings bool `json:"ASK_REVIEW_GREETINGS"`
+
+	// reminders are sent at these durations before reservation deadline
+	ReservationReminders []time.Duration `json:"ASK_RESERVATION_REMINDERS"`
+
+	// how long role is held for the first user in waitlist
+	WaitlistOfferDuration time.Duration `json:"ASK_WAITLIST_OFFER_DURATION"`
+
+	// limits of deadline extensions per reservation, zero disables extensions
+	MaxReservationExtensions    int `json:"ASK_MAX_RESERVATION_EXTENSIONS"`
+	MaxReservationExtensionDays int `json:"ASK_MAX_RESERVATION_EXTENSION_DAYS"`
+
+	// polls get neutral answer for those who just want to see results
+	NeutralPollAnswer bool `json:"ASK_NEUTRAL_POLL_ANSWER"`
+
+	// how long poll is open, groups of roles could have their own duration
+	PollDuration       time.Duration            `json:"ASK_POLL_DURATION"`
+	GroupPollDurations map[string]time.Duration `json:"ASK_GROUP_POLL_DURATIONS"`
+	// how far ahead polls are planned
+	PollHorizon time.Duration `json:"ASK_POLL_HORIZON"`
+
+	PollRules
 	OrganizationHashtags
 }
 
@@ -50,11 +89,187 @@ func ConfigFromEnv() *Config {
 			"reservation duration", os.Getenv("ASK_NO_CONFIRM_RESERVATION"))
 	}
 
+	// one reservation per user by default
+	max_reservations := 1
+	if value := os.Getenv("ASK_MAX_RESERVATIONS"); len(value) > 0 {
+		max_reservations, err = strconv.Atoi(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse max reservations",
+				"error", err,
+				"max reservations", value)
+		}
+	}
+
+	review_greetings, err := strconv.ParseBool(os.Getenv("ASK_REVIEW_GREETINGS"))
+	if err != nil {
+		zap.S().Warnw("failed to parse review greetings",
+			"error", err,
+			"review greetings", os.Getenv("ASK_REVIEW_GREETINGS"))
+	}
+
+	var reminders []time.Duration
+	for _, value := range strings.Split(os.Getenv("ASK_RESERVATION_REMINDERS"), ",") {
+		if len(strings.TrimSpace(value)) == 0 {
+			continue
+		}
+
+		reminder, err := str2duration.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			zap.S().Warnw("failed to parse reservation reminder",
+				"error", err,
+				"reminder", value)
+			continue
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	// one day by default
+	waitlist_offer := 24 * time.Hour
+	if value := os.Getenv("ASK_WAITLIST_OFFER_DURATION"); len(value) > 0 {
+		waitlist_offer, err = str2duration.ParseDuration(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse waitlist offer duration",
+				"error", err,
+				"waitlist offer duration", value)
+		}
+	}
+
+	// no quorum by default
+	poll_quorum := 0
+	if value := os.Getenv("ASK_POLL_QUORUM"); len(value) > 0 {
+		poll_quorum, err = strconv.Atoi(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse poll quorum",
+				"error", err,
+				"poll quorum", value)
+		}
+	}
+
+	// any lead wins by default
+	poll_min_lead := 0
+	if value := os.Getenv("ASK_POLL_MIN_LEAD"); len(value) > 0 {
+		poll_min_lead, err = strconv.Atoi(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse poll min lead",
+				"error", err,
+				"poll min lead", value)
+		}
+	}
+
+	// reservations are lost by default
+	poll_no_one := PollNoOneRules.Release
+	if value := os.Getenv("ASK_POLL_NO_ONE"); len(value) > 0 {
+		poll_no_one = PollNoOneRule(value)
+	}
+
+	poll_runoff, err := strconv.ParseBool(os.Getenv("ASK_POLL_RUNOFF"))
+	if err != nil {
+		zap.S().Warnw("failed to parse poll runoff",
+			"error", err,
+			"poll runoff", os.Getenv("ASK_POLL_RUNOFF"))
+	}
+
+	neutral_poll_answer, err := strconv.ParseBool(os.Getenv("ASK_NEUTRAL_POLL_ANSWER"))
+	if err != nil {
+		zap.S().Warnw("failed to parse neutral poll answer",
+			"error", err,
+			"neutral poll answer", os.Getenv("ASK_NEUTRAL_POLL_ANSWER"))
+	}
+
+	// one day by default
+	poll_duration := 24 * time.Hour
+	if value := os.Getenv("ASK_POLL_DURATION"); len(value) > 0 {
+		poll_duration, err = str2duration.ParseDuration(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse poll duration",
+				"error", err,
+				"poll duration", value)
+		}
+	}
+
+	// group=duration separated by comma
+	group_poll_durations := make(map[string]time.Duration)
+	for _, value := range strings.Split(os.Getenv("ASK_GROUP_POLL_DURATIONS"), ",") {
+		if len(strings.TrimSpace(value)) == 0 {
+			continue
+		}
+
+		group, duration, ok := strings.Cut(value, "=")
+		if !ok {
+			zap.S().Warnw("failed to parse group poll duration",
+				"group poll duration", value)
+			continue
+		}
+
+		group_duration, err := str2duration.ParseDuration(strings.TrimSpace(duration))
+		if err != nil {
+			zap.S().Warnw("failed to parse group poll duration",
+				"error", err,
+				"group poll duration", value)
+			continue
+		}
+
+		group_poll_durations[strings.TrimSpace(group)] = group_duration
+	}
+
+	// two weeks by default
+	poll_horizon := 14 * 24 * time.Hour
+	if value := os.Getenv("ASK_POLL_HORIZON"); len(value) > 0 {
+		poll_horizon, err = str2duration.ParseDuration(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse poll horizon",
+				"error", err,
+				"poll horizon", value)
+		}
+	}
+
+	// extensions are turned off by default
+	max_extensions := 0
+	if value := os.Getenv("ASK_MAX_RESERVATION_EXTENSIONS"); len(value) > 0 {
+		max_extensions, err = strconv.Atoi(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse max reservation extensions",
+				"error", err,
+				"max reservation extensions", value)
+		}
+	}
+
+	max_extension_days := 0
+	if value := os.Getenv("ASK_MAX_RESERVATION_EXTENSION_DAYS"); len(value) > 0 {
+		max_extension_days, err = strconv.Atoi(value)
+		if err != nil {
+			zap.S().Warnw("failed to parse max reservation extension days",
+				"error", err,
+				"max reservation extension days", value)
+		}
+	}
+
 	return &Config{
-		Timezone:             timezone,
-		Deadline:             deadline,
-		ReservationDuration:  reservation,
-		NoConfirmReservation: no_confirm_reservation,
+		Timezone:              timezone,
+		Deadline:              deadline,
+		ReservationDuration:   reservation,
+		NoConfirmReservation:  no_confirm_reservation,
+		MaxReservations:       max_reservations,
+		ReviewGreetings:       review_greetings,
+		ReservationReminders:  reminders,
+		WaitlistOfferDuration: waitlist_offer,
+
+		MaxReservationExtensions:    max_extensions,
+		MaxReservationExtensionDays: max_extension_days,
+
+		NeutralPollAnswer: neutral_poll_answer,
+
+		PollDuration:       poll_duration,
+		GroupPollDurations: group_poll_durations,
+		PollHorizon:        poll_horizon,
+
+		PollRules: PollRules{
+			Quorum:  poll_quorum,
+			MinLead: poll_min_lead,
+			NoOne:   poll_no_one,
+			Runoff:  poll_runoff,
+		},
 
 		// hashtags
 		OrganizationHashtags: OrganizationHashtags{
@@ -76,7 +291,47 @@ func (c *Config) Validate() error {
 		return errors.New("ask reservation duration is not provided")
 	}
 
-	// no confirm reservation default is false
+	if c.MaxReservations < 1 {
+		return errors.New("ask max reservations should be positive")
+	}
+
+	// no confirm reservation and review greetings default is false
+
+	for _, reminder := range c.ReservationReminders {
+		if reminder <= 0 {
+			return errors.New("ask reservation reminder should be positive")
+		}
+	}
+
+	if c.WaitlistOfferDuration <= 0 {
+		return errors.New("ask waitlist offer duration should be positive")
+	}
+
+	// extensions are disabled by default
+
+	if c.Quorum < 0 {
+		return errors.New("ask poll quorum should not be negative")
+	}
+	if c.MinLead < 0 {
+		return errors.New("ask poll min lead should not be negative")
+	}
+	// empty rule is release
+	if len(c.NoOne) > 0 && c.NoOne != PollNoOneRules.Release && c.NoOne != PollNoOneRules.Ignore {
+		return errors.New("ask poll no one rule should be Release or Ignore")
+	}
+	// runoff and neutral poll answer are off by default
+
+	if c.PollDuration <= 0 {
+		return errors.New("ask poll duration should be positive")
+	}
+	for _, duration := range c.GroupPollDurations {
+		if duration <= 0 {
+			return errors.New("ask group poll duration should be positive")
+		}
+	}
+	if c.PollHorizon <= 0 {
+		return errors.New("ask poll horizon should be positive")
+	}
 
 	if len(c.PollHashtag) == 0 {
 		return errors.New("ask poll hashtag is not provided")
@@ -95,6 +350,25 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func (a *Ask) NeutralPollAnswer() bool {
+	return a.config.NeutralPollAnswer
+}
+
+// duration of group overrides the common one
+func (a *Ask) PollDuration(role Role) time.Duration {
+	if role.Group.Valid {
+		if duration, ok := a.config.GroupPollDurations[role.Group.String]; ok {
+			return duration
+		}
+	}
+
+	return a.config.PollDuration
+}
+
+func (a *Ask) PollHorizon() time.Duration {
+	return a.config.PollHorizon
+}
+
 func (a *Ask) OrganizationHashtags() *OrganizationHashtags {
 	return &a.config.OrganizationHashtags
 }
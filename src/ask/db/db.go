@@ -1,12 +1,13 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hori-ryota/zaperr"
 	"github.com/jmoiron/sqlx"
@@ -16,20 +17,80 @@ import (
 )
 
 type DB struct {
+	// the only connection for writes, so writers wait for each other
+	// in the queue of pool instead of failing with database is locked
 	sql *sqlx.DB
+
+	// in write ahead log mode reads do not block writes and vice versa
+	read *sqlx.DB
+}
+
+var busyTimeout = 5 * time.Second
+
+// SetBusyTimeout sets how long connection waits for lock of other process
+// (like sqlite-web) before failing. It is applied to databases opened after.
+func SetBusyTimeout(timeout time.Duration) {
+	busyTimeout = timeout
 }
 
 func NewDB(connection string) (*DB, error) {
 	// add parameters
 
 	// enable foreign key constaints
-	// enable write ahead log
-	conn_with_params := fmt.Sprintf("%s?_foreign_keys=true", connection)
+	// wait for locks
+	params := []string{
+		"_foreign_keys=true",
+		fmt.Sprintf("_busy_timeout=%d", busyTimeout.Milliseconds()),
+	}
+
+	read_only := strings.Contains(connection, "mode=ro")
+	memory := connection == ":memory:" || strings.Contains(connection, "mode=memory")
+
+	// enable write ahead log, it is persistent for file
+	if !read_only && !memory {
+		params = append(params, "_journal_mode=WAL")
+	}
+
+	// take write lock at the begin of transaction,
+	// lock can not be upgraded from read to write without busy error
+	write, err := open(connection, append(params, "_txlock=immediate"))
+	if err != nil {
+		return nil, err
+	}
+	write.SetMaxOpenConns(1)
+
+	// every connection to :memory: is a new database,
+	// shared cache fails on table locks without waiting
+	if memory {
+		return &DB{
+			sql:  write,
+			read: write,
+		}, nil
+	}
+
+	read, err := open(connection, append(params, "_query_only=true"))
+	if err != nil {
+		write.Close()
+		return nil, err
+	}
+
+	return &DB{
+		sql:  write,
+		read: read,
+	}, nil
+}
+
+func open(connection string, params []string) (*sqlx.DB, error) {
+	separator := "?"
+	if strings.Contains(connection, "?") {
+		separator = "&"
+	}
+	conn_with_params := connection + separator + strings.Join(params, "&")
 
 	db, err := sqlx.Open("sqlite3", conn_with_params)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to open sqlite3 read",
-			zap.String("connection", connection))
+		return nil, zaperr.Wrap(err, "failed to open sqlite3",
+			zap.String("connection", conn_with_params))
 	}
 
 	db.Mapper = reflectx.NewMapperTagFunc("db",
@@ -39,29 +100,26 @@ func NewDB(connection string) (*DB, error) {
 		})
 
 	if err := db.Ping(); err != nil {
-		return nil, zaperr.Wrap(err, "failed to ping read database")
+		db.Close()
+		return nil, zaperr.Wrap(err, "failed to ping database",
+			zap.String("connection", conn_with_params))
 	}
 
-	return &DB{
-		sql: db,
-	}, nil
+	return db, nil
 }
 
-func (db *DB) Init(filename string, allow_deletion bool) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to open file",
-			zap.String("filename", filename))
-	}
+// database is opened read only, any attempt to write fails
+func NewReadOnlyDB(connection string) (*DB, error) {
+	return NewDB(fmt.Sprintf("file:%s?mode=ro", connection))
+}
 
-	schema, err := io.ReadAll(file)
-	file.Close()
+func (db *DB) Init(filename string, allow_deletion bool, migrations []DataMigration) error {
+	schema, err := readSchema(filename)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to  read all from file",
-			zap.String("filename", filename))
+		return err
 	}
 
-	err = db.Migrate(string(schema), allow_deletion)
+	err = db.Migrate(schema, allow_deletion, migrations)
 	if err != nil {
 		return err
 	}
@@ -69,60 +127,43 @@ func (db *DB) Init(filename string, allow_deletion bool) error {
 	return nil
 }
 
-func (db *DB) Select(dest interface{}, query string, args ...interface{}) error {
-	return db.sql.Select(dest, query, args...)
-}
-
-func (db *DB) Get(dest interface{}, query string, args ...interface{}) error {
-	return db.sql.Get(dest, query, args...)
-}
-
-func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return db.sql.Exec(query, args...)
-}
-
-func (db *DB) NewTransaction() (*sqlx.Tx, error) {
-	return db.sql.Beginx()
-}
-
-func (db *DB) LoadCsv(name string) error {
-	file, err := os.Open(name)
+func readSchema(filename string) (string, error) {
+	file, err := os.Open(filename)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to open csv file",
-			zap.String("filename", name))
+		return "", zaperr.Wrap(err, "failed to open file",
+			zap.String("filename", filename))
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	content, err := reader.ReadAll()
+	schema, err := io.ReadAll(file)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to read all content from csv file")
+		return "", zaperr.Wrap(err, "failed to  read all from file",
+			zap.String("filename", filename))
 	}
 
-	// delete header row
-	content = content[1:]
+	return string(schema), nil
+}
 
-	stmt, err := db.sql.Prepare(`INSERT INTO roles 
-		(name, tag, shown_name, accusative_name, caption_name)
-		VALUES (?, ?, ?, ?, ?)`)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to prepare sql statement for csv")
+func (db *DB) Close() error {
+	if db.read != db.sql {
+		db.read.Close()
 	}
 
-	tx, err := db.sql.Begin()
-	if err != nil {
-		return zaperr.Wrap(err, "failed to begin sql transaction")
-	}
+	return db.sql.Close()
+}
 
-	for _, record := range content {
-		_, err := tx.Stmt(stmt).Exec(record[0], record[1], record[2], record[3], record[4])
-		if err != nil {
-			tx.Rollback()
-			return zaperr.Wrap(err, "failed to execute statement in transaction",
-				zap.Any("stmt", stmt),
-				zap.Any("record", record))
-		}
-	}
+func (db *DB) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.read.SelectContext(ctx, dest, query, args...)
+}
+
+func (db *DB) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.read.GetContext(ctx, dest, query, args...)
+}
 
-	return tx.Commit()
+func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.sql.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) NewTransaction() (*sqlx.Tx, error) {
+	return db.sql.Beginx()
 }
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+type BackupConfig struct {
+	Dir string
+
+	// zero means no limit
+	Keep   int
+	MaxAge time.Duration
+}
+
+const snapshotPrefix = "snapshot_"
+const snapshotSuffix = ".db"
+
+// names are sorted in chronological order
+const snapshotTimeLayout = "20060102_150405.000"
+
+// Backup writes consistent snapshot of database into dir
+// and returns its filename. Database without tables is not saved.
+func (db *DB) Backup(ctx context.Context, dir string) (string, error) {
+	fresh, err := isFresh(db.read)
+	if err != nil {
+		return "", err
+	}
+	if fresh {
+		return "", nil
+	}
+
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return "", zaperr.Wrap(err, "failed to create backup directory",
+			zap.String("dir", dir))
+	}
+
+	name := snapshotPrefix + time.Now().UTC().Format(snapshotTimeLayout) + snapshotSuffix
+	filename := filepath.Join(dir, name)
+
+	dest, err := sqlx.Open("sqlite3", filename)
+	if err != nil {
+		return "", zaperr.Wrap(err, "failed to open snapshot",
+			zap.String("filename", filename))
+	}
+	defer dest.Close()
+
+	err = copyDatabase(ctx, dest, db.read)
+	if err != nil {
+		os.Remove(filename)
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// Restore replaces content of database with snapshot.
+func (db *DB) Restore(snapshot *DB) error {
+	return copyDatabase(context.Background(), db.sql, snapshot.read)
+}
+
+// Copy returns in-memory database with the same content.
+func (db *DB) Copy() (*DB, error) {
+	copied, err := NewDB(":memory:")
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyDatabase(context.Background(), copied.sql, db.read)
+	if err != nil {
+		copied.Close()
+		return nil, err
+	}
+
+	return copied, nil
+}
+
+func (db *DB) CheckIntegrity() error {
+	var result []string
+
+	err := db.read.Select(&result, "PRAGMA integrity_check")
+	if err != nil {
+		return zaperr.Wrap(err, "failed to check integrity")
+	}
+
+	if len(result) != 1 || result[0] != "ok" {
+		err := errors.New("database is corrupted")
+		return zaperr.Wrap(err, "",
+			zap.Strings("result", result))
+	}
+
+	return nil
+}
+
+// online backup api copies pages of src into dest
+// without locking src for the whole time
+func copyDatabase(ctx context.Context, dest *sqlx.DB, src *sqlx.DB) error {
+	dest_conn, err := dest.Conn(ctx)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to get destination connection")
+	}
+	defer dest_conn.Close()
+
+	src_conn, err := src.Conn(ctx)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to get source connection")
+	}
+	defer src_conn.Close()
+
+	return dest_conn.Raw(func(dest_driver interface{}) error {
+		return src_conn.Raw(func(src_driver interface{}) error {
+			dest_sqlite, ok := dest_driver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("destination is not sqlite3 connection")
+			}
+			src_sqlite, ok := src_driver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("source is not sqlite3 connection")
+			}
+
+			backup, err := dest_sqlite.Backup("main", src_sqlite, "main")
+			if err != nil {
+				return zaperr.Wrap(err, "failed to init backup")
+			}
+
+			done, err := backup.Step(-1)
+			if err != nil {
+				backup.Finish()
+				return zaperr.Wrap(err, "failed to copy pages")
+			}
+			if !done {
+				backup.Finish()
+				return errors.New("failed to copy pages: database is busy or locked")
+			}
+
+			err = backup.Finish()
+			if err != nil {
+				return zaperr.Wrap(err, "failed to finish backup")
+			}
+
+			return nil
+		})
+	})
+}
+
+// PruneBackups deletes snapshots which are over the limits of config.
+// The latest snapshot is always kept.
+func PruneBackups(config *BackupConfig) error {
+	entries, err := os.ReadDir(config.Dir)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to read backup directory",
+			zap.String("dir", config.Dir))
+	}
+
+	type snapshot struct {
+		name      string
+		timestamp time.Time
+	}
+
+	snapshots := []snapshot{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() ||
+			!strings.HasPrefix(name, snapshotPrefix) ||
+			!strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+
+		timestamp, err := time.Parse(snapshotTimeLayout,
+			strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix))
+		if err != nil {
+			// not made by Backup
+			continue
+		}
+
+		snapshots = append(snapshots, snapshot{name, timestamp})
+	}
+
+	// newest first
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].timestamp.After(snapshots[j].timestamp)
+	})
+
+	now := time.Now()
+	for i := 1; i < len(snapshots); i++ {
+		too_many := config.Keep > 0 && i >= config.Keep
+		too_old := config.MaxAge > 0 && now.Sub(snapshots[i].timestamp) > config.MaxAge
+
+		if !too_many && !too_old {
+			continue
+		}
+
+		filename := filepath.Join(config.Dir, snapshots[i].name)
+		err := os.Remove(filename)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to remove snapshot",
+				zap.String("filename", filename))
+		}
+
+		zap.S().Infow("snapshot is pruned",
+			"filename", filename,
+			"reason", fmt.Sprintf("too many: %t, too old: %t", too_many, too_old))
+	}
+
+	return nil
+}
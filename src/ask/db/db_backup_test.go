@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	d := memory(t, actual_schema)
+	dir := t.TempDir()
+
+	_, err := d.Exec(context.Background(), "INSERT INTO points (vk_id, diff) VALUES (1, 10), (2, 20)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename, err := d.Backup(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := NewReadOnlyDB(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snapshot.Close()
+
+	err = snapshot.CheckIntegrity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Exec(context.Background(), "DELETE FROM points")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = d.Restore(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = d.Get(context.Background(), &count, "SELECT count(*) FROM points")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("wrong count of restored rows: %d", count)
+	}
+}
+
+func TestBackupOfFreshDatabase(t *testing.T) {
+	d := memory(t, "")
+
+	filename, err := d.Backup(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filename) > 0 {
+		t.Errorf("fresh database should not be saved: %s", filename)
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+
+	create := func(age time.Duration) string {
+		name := snapshotPrefix + now.Add(-age).Format(snapshotTimeLayout) + snapshotSuffix
+		err := os.WriteFile(filepath.Join(dir, name), nil, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	latest := create(48 * time.Hour)
+	second := create(72 * time.Hour)
+	create(96 * time.Hour)
+	create(30 * 24 * time.Hour)
+
+	// not a snapshot
+	err := os.WriteFile(filepath.Join(dir, "ask.db"), nil, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = PruneBackups(&BackupConfig{
+		Dir:    dir,
+		Keep:   3,
+		MaxAge: 7 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Errorf("wrong count of files after pruning: %v", entries)
+	}
+
+	// latest is kept even if it is too old
+	err = PruneBackups(&BackupConfig{
+		Dir:    dir,
+		MaxAge: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{latest, second} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if name == latest && err != nil {
+			t.Errorf("latest snapshot is pruned: %s", err)
+		}
+		if name == second && err == nil {
+			t.Errorf("old snapshot is not pruned: %s", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Checker only prepares statements against database and never executes them.
+// Selected destinations are left untouched, failures are collected in Err.
+type Checker struct {
+	sql *sqlx.DB
+
+	failures []error
+}
+
+func (db *DB) Checker() *Checker {
+	return &Checker{
+		sql: db.sql,
+	}
+}
+
+func (c *Checker) prepare(ctx context.Context, query string, args []interface{}) {
+	stmt, err := c.sql.PreparexContext(ctx, query)
+	if err != nil {
+		c.failures = append(c.failures, zaperr.Wrap(err, "failed to prepare statement",
+			zap.String("query", query),
+			zap.Any("args", args)))
+		return
+	}
+
+	stmt.Close()
+}
+
+func (c *Checker) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	c.prepare(ctx, query, args)
+	return nil
+}
+
+func (c *Checker) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	c.prepare(ctx, query, args)
+	return nil
+}
+
+func (c *Checker) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.prepare(ctx, query, args)
+	return checkerResult{}, nil
+}
+
+func (c *Checker) Transaction(ctx context.Context, fn func(q Queryer) error) error {
+	return fn(c)
+}
+
+// all failed statements joined
+func (c *Checker) Err() error {
+	return errors.Join(c.failures...)
+}
+
+type checkerResult struct{}
+
+func (checkerResult) LastInsertId() (int64, error) { return 0, nil }
+func (checkerResult) RowsAffected() (int64, error) { return 0, nil }
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/jmoiron/sqlx"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+// Declarative migration copies only columns with the same names,
+// so renaming or transforming values is done by data migrations.
+//
+// Before is executed on the old schema (e.g. to stash values into temp table),
+// After is executed on the new one. Both run in the transaction of schema migration.
+// Applied migrations are recorded in schema_migrations and never run again.
+type DataMigration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+}
+
+const migrationsTable = "schema_migrations"
+
+const migrationsTableSql = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT PRIMARY KEY NOT NULL,
+    name TEXT NOT NULL,
+    timestamp DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+type appliedMigration struct {
+	Version int `db:"version"`
+}
+
+func validateMigrations(migrations []DataMigration) error {
+	for i := range migrations {
+		if migrations[i].Version <= 0 {
+			err := errors.New("data migration version should be positive")
+			return zaperr.Wrap(err, "",
+				zap.Int("version", migrations[i].Version),
+				zap.String("name", migrations[i].Name))
+		}
+
+		if i > 0 && migrations[i-1].Version >= migrations[i].Version {
+			err := errors.New("data migrations should be ordered by unique versions")
+			return zaperr.Wrap(err, "",
+				zap.Int("previous", migrations[i-1].Version),
+				zap.Int("version", migrations[i].Version))
+		}
+	}
+
+	return nil
+}
+
+// migrations which are not recorded in database
+func pendingMigrations(q selecter, migrations []DataMigration) ([]DataMigration, error) {
+	var tables []string
+
+	query := sqlf.From("sqlite_schema").
+		Select("name").
+		Where("type = 'table'").
+		Where("name = ?", migrationsTable)
+
+	err := q.Select(&tables, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to find schema migrations table",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(tables) == 0 {
+		return migrations, nil
+	}
+
+	var applied []appliedMigration
+
+	query = sqlf.From(migrationsTable).
+		Bind(&appliedMigration{})
+
+	err = q.Select(&applied, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get applied migrations",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	versions := map[int]bool{}
+	for _, a := range applied {
+		versions[a.Version] = true
+	}
+
+	var pending []DataMigration
+	for _, m := range migrations {
+		if !versions[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+
+	return pending, nil
+}
+
+func isFresh(q selecter) (bool, error) {
+	var count []int
+
+	query := sqlf.From("sqlite_schema").
+		Select("count(*)").
+		Where("type = 'table'").
+		Where("name NOT IN ('sqlite_sequence', ?)", migrationsTable)
+
+	err := q.Select(&count, query.String(), query.Args()...)
+	if err != nil {
+		return false, zaperr.Wrap(err, "failed to count tables",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return count[0] == 0, nil
+}
+
+func execMigrationStep(transaction *sqlx.Tx, migration DataMigration, step string, statement string) error {
+	if len(statement) == 0 {
+		return nil
+	}
+
+	zap.S().Infow("execute data migration",
+		"version", migration.Version,
+		"name", migration.Name,
+		"step", step,
+		"statement", statement)
+
+	_, err := transaction.Exec(statement)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to execute data migration",
+			zap.Int("version", migration.Version),
+			zap.String("name", migration.Name),
+			zap.String("step", step),
+			zap.String("statement", statement))
+	}
+
+	return nil
+}
+
+func recordMigration(transaction *sqlx.Tx, migration DataMigration) error {
+	query := sqlf.InsertInto(migrationsTable).
+		Set("version", migration.Version).
+		Set("name", migration.Name)
+
+	_, err := transaction.Exec(query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to record data migration",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDataMigration(t *testing.T) {
+	d := memory(t, actual_schema)
+
+	_, err := d.Exec(context.Background(), `INSERT INTO roles (name, tag) VALUES ('alice', '#alice')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations := []DataMigration{
+		{
+			Version: 1,
+			Name:    "rename tag to hashtag",
+			Before:  "CREATE TEMP TABLE roles_tags AS SELECT name, tag FROM roles",
+			After: `UPDATE roles SET hashtag = (SELECT tag FROM roles_tags WHERE roles_tags.name = roles.name);
+				DROP TABLE roles_tags`,
+		},
+	}
+
+	p, err := d.Plan(desired_schema, true, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Fresh || len(p.DataMigrations) != 1 {
+		t.Fatalf("wrong data migrations in plan: %v", p)
+	}
+
+	err = d.Migrate(desired_schema, true, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hashtag string
+	err = d.Get(context.Background(), &hashtag, "SELECT hashtag FROM roles WHERE name = 'alice'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashtag != "#alice" {
+		t.Errorf("value is not migrated: %s", hashtag)
+	}
+
+	// second run is skipped
+	p, err = d.Plan(desired_schema, true, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Empty() {
+		t.Errorf("plan should be empty after migration: %v", p)
+	}
+}
+
+func TestDataMigrationFresh(t *testing.T) {
+	d := memory(t, "")
+
+	migrations := []DataMigration{
+		{Version: 1, Name: "fails on fresh database", After: "UPDATE missing SET value = 1"},
+	}
+
+	err := d.Migrate(desired_schema, false, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := d.Plan(desired_schema, false, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.DataMigrations) != 0 {
+		t.Errorf("migration should be recorded: %v", p.DataMigrations)
+	}
+}
+
+func TestDataMigrationsOrder(t *testing.T) {
+	d := memory(t, "")
+
+	err := d.Migrate(desired_schema, false, []DataMigration{{Version: 2}, {Version: 1}})
+	if err == nil {
+		t.Error("unordered migrations should fail")
+	}
+}
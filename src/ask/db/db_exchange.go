@@ -0,0 +1,427 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+type Format string
+
+var Formats = struct {
+	CSV  Format
+	JSON Format
+}{
+	CSV:  "csv",
+	JSON: "json",
+}
+
+func FormatFromFilename(filename string) (Format, error) {
+	switch {
+	case strings.HasSuffix(filename, ".csv"):
+		return Formats.CSV, nil
+	case strings.HasSuffix(filename, ".json"):
+		return Formats.JSON, nil
+	}
+
+	err := errors.New("unknown format of file")
+	return "", zaperr.Wrap(err, "",
+		zap.String("filename", filename))
+}
+
+// field of row struct bound to column
+type column struct {
+	name  string
+	index []int
+}
+
+// columns by db tags, embedded structs are flattened
+func columns(t reflect.Type) []column {
+	var result []column
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, c := range columns(field.Type) {
+				result = append(result, column{
+					name:  c.name,
+					index: append([]int{i}, c.index...),
+				})
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("db")
+		if len(tag) == 0 || tag == "-" || !field.IsExported() {
+			continue
+		}
+
+		result = append(result, column{
+			name:  strings.Trim(tag, "[]"),
+			index: []int{i},
+		})
+	}
+
+	return result
+}
+
+// Export writes all rows of table. Row is struct with db tags,
+// values are converted by their driver.Valuer if any.
+func (db *DB) Export(w io.Writer, format Format, table string, row interface{}) error {
+	t := reflect.TypeOf(row)
+	cols := columns(t)
+
+	rows := reflect.New(reflect.SliceOf(t))
+
+	query := sqlf.From(table).
+		Bind(reflect.New(t).Interface())
+
+	err := db.read.Select(rows.Interface(), query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to select rows for export",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	records := make([][]interface{}, rows.Elem().Len())
+	for i := range records {
+		records[i] = make([]interface{}, len(cols))
+
+		for j, c := range cols {
+			value, err := exportValue(rows.Elem().Index(i).FieldByIndex(c.index))
+			if err != nil {
+				return zaperr.Wrap(err, "failed to export value",
+					zap.String("table", table),
+					zap.Int("row", i),
+					zap.String("column", c.name))
+			}
+
+			records[i][j] = value
+		}
+	}
+
+	switch format {
+	case Formats.CSV:
+		return writeCsv(w, cols, records)
+	case Formats.JSON:
+		return writeJson(w, cols, records)
+	}
+
+	return errors.New("unknown export format")
+}
+
+func exportValue(field reflect.Value) (interface{}, error) {
+	if valuer, ok := field.Interface().(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return field.Interface(), nil
+}
+
+func writeCsv(w io.Writer, cols []column, records [][]interface{}) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(cols))
+	for i := range cols {
+		header[i] = cols[i].name
+	}
+
+	err := writer.Write(header)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to write csv header")
+	}
+
+	for _, record := range records {
+		cells := make([]string, len(record))
+
+		for i, value := range record {
+			switch v := value.(type) {
+			case nil:
+				cells[i] = ""
+			case time.Time:
+				cells[i] = v.Format(time.RFC3339)
+			case []byte:
+				cells[i] = string(v)
+			default:
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+
+		err := writer.Write(cells)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to write csv record")
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeJson(w io.Writer, cols []column, records [][]interface{}) error {
+	objects := make([]map[string]interface{}, len(records))
+
+	for i, record := range records {
+		objects[i] = map[string]interface{}{}
+
+		for j, value := range record {
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+
+			objects[i][cols[j].name] = value
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(objects)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to encode json")
+	}
+
+	return nil
+}
+
+// Import validates all records by scanning them into row struct
+// and inserts them in one transaction. Only present columns are set,
+// others get default values.
+func (db *DB) Import(r io.Reader, format Format, table string, row interface{}) error {
+	t := reflect.TypeOf(row)
+
+	by_name := map[string]column{}
+	for _, c := range columns(t) {
+		by_name[c.name] = c
+	}
+
+	var header []string
+	var records [][]interface{}
+	var err error
+
+	switch format {
+	case Formats.CSV:
+		header, records, err = readCsv(r)
+	case Formats.JSON:
+		header, records, err = readJson(r)
+	default:
+		err = errors.New("unknown import format")
+	}
+	if err != nil {
+		return err
+	}
+
+	cols := make([]column, len(header))
+	for i, name := range header {
+		c, ok := by_name[name]
+		if !ok {
+			err := errors.New("unknown column")
+			return zaperr.Wrap(err, "",
+				zap.String("table", table),
+				zap.String("column", name))
+		}
+
+		cols[i] = c
+	}
+
+	queries := make([]*sqlf.Stmt, len(records))
+	for i, record := range records {
+		value := reflect.New(t).Elem()
+
+		query := sqlf.InsertInto(table)
+		for j, c := range cols {
+			field := value.FieldByIndex(c.index)
+
+			err := importValue(field, record[j])
+			if err != nil {
+				return zaperr.Wrap(err, "failed to import value",
+					zap.String("table", table),
+					zap.Int("row", i),
+					zap.String("column", c.name),
+					zap.Any("value", record[j]))
+			}
+
+			query.Set(fmt.Sprintf("[%s]", c.name), field.Interface())
+		}
+
+		queries[i] = query
+	}
+
+	tx, err := db.NewTransaction()
+	if err != nil {
+		return zaperr.Wrap(err, "failed to begin new transaction",
+			zap.String("reason", "import"))
+	}
+
+	for i, query := range queries {
+		_, err := tx.Exec(query.String(), query.Args()...)
+		if err != nil {
+			tx.Rollback()
+			return zaperr.Wrap(err, "failed to insert imported row",
+				zap.String("table", table),
+				zap.Int("row", i),
+				zap.String("query", query.String()),
+				zap.Any("args", query.Args()))
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return zaperr.Wrap(err, "failed to commit transaction",
+			zap.String("reason", "import"))
+	}
+
+	return nil
+}
+
+// empty cells are null
+func readCsv(r io.Reader) ([]string, [][]interface{}, error) {
+	content, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, zaperr.Wrap(err, "failed to read all content from csv file")
+	}
+	if len(content) == 0 {
+		return nil, nil, errors.New("csv file has no header")
+	}
+
+	records := make([][]interface{}, len(content)-1)
+	for i, cells := range content[1:] {
+		records[i] = make([]interface{}, len(cells))
+
+		for j, cell := range cells {
+			if len(cell) > 0 {
+				records[i][j] = cell
+			}
+		}
+	}
+
+	return content[0], records, nil
+}
+
+// all objects should have the same keys
+func readJson(r io.Reader) ([]string, [][]interface{}, error) {
+	var objects []map[string]interface{}
+
+	err := json.NewDecoder(r).Decode(&objects)
+	if err != nil {
+		return nil, nil, zaperr.Wrap(err, "failed to decode json")
+	}
+
+	var header []string
+	if len(objects) > 0 {
+		for key := range objects[0] {
+			header = append(header, key)
+		}
+	}
+
+	records := make([][]interface{}, len(objects))
+	for i, object := range objects {
+		if len(object) != len(header) {
+			err := errors.New("json objects have different keys")
+			return nil, nil, zaperr.Wrap(err, "",
+				zap.Int("row", i))
+		}
+
+		records[i] = make([]interface{}, len(header))
+		for j, key := range header {
+			value, ok := object[key]
+			if !ok {
+				err := errors.New("json objects have different keys")
+				return nil, nil, zaperr.Wrap(err, "",
+					zap.Int("row", i),
+					zap.String("key", key))
+			}
+
+			// numbers are decoded as float64
+			if f, ok := value.(float64); ok && f == math.Trunc(f) {
+				value = int64(f)
+			}
+
+			records[i][j] = value
+		}
+	}
+
+	return header, records, nil
+}
+
+// value is nil, string, int64, float64 or bool
+func importValue(field reflect.Value, value interface{}) error {
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	if _, ok := field.Interface().(time.Time); ok {
+		s, ok := value.(string)
+		if !ok {
+			return errors.New("time should be string")
+		}
+
+		for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+			t, err := time.Parse(layout, s)
+			if err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+
+		return errors.New("failed to parse time")
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		if value == nil {
+			field.SetString("")
+			return nil
+		}
+
+		field.SetString(fmt.Sprint(value))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := value.(type) {
+		case int64:
+			field.SetInt(v)
+			return nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return err
+			}
+
+			field.SetInt(i)
+			return nil
+		case nil:
+			return errors.New("value is not nullable")
+		}
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+
+			field.SetBool(b)
+			return nil
+		case nil:
+			return errors.New("value is not nullable")
+		}
+	}
+
+	return errors.New("unsupported type of value")
+}
@@ -19,53 +19,34 @@ var keywords = map[string]string{
 }
 
 type Object struct {
-	Name string `db:"name"`
-	Kind string `db:"type"`
-	Sql  string `db:"sql"`
+	Name  string `db:"name" json:"name"`
+	Kind  string `db:"type" json:"type"`
+	Table string `db:"tbl_name" json:"table"`
+	Sql   string `db:"sql" json:"sql"`
 }
 
 type Column struct {
 	Name string `db:"name"`
 }
 
-func (db *DB) Migrate(schema string, allow_deletion bool) error {
-	desired, err := sqlx.Open("sqlite3", ":memory:")
+func (db *DB) Migrate(schema string, allow_deletion bool, migrations []DataMigration) error {
+	err := validateMigrations(migrations)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to open in-memory database")
-	}
-
-	_, err = desired.Exec(string(schema))
-	if err != nil {
-		return zaperr.Wrap(err, "failed to execute schema on pristine database",
-			zap.String("schema", schema))
+		return err
 	}
 
-	actual_tables := []Object{}
-	desired_tables := []Object{}
-
-	query := sqlf.From("sqlite_schema").
-		Bind(&Object{}).
-		Where("type = 'table'").
-		Where("name != 'sqlite_sequence'").
-		OrderBy("name")
-
-	err = db.Select(&actual_tables, query.String())
+	desired, err := pristine(schema)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to get actual tables",
-			zap.String("statement", query.String()),
-			zap.Any("args", query.Args()))
+		return err
 	}
+	defer desired.Close()
 
-	err = desired.Select(&desired_tables, query.String())
+	p, err := plan(db.sql, desired, allow_deletion, migrations)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to get desired tables",
-			zap.String("statement", query.String()),
-			zap.Any("args", query.Args()))
+		return err
 	}
 
-	new_tables, old_tables, modified_tables := getDifference(actual_tables, desired_tables)
-
-	zap.S().Debugw("", "new", new_tables, "delete", old_tables, "modified", modified_tables)
+	zap.S().Infow("migration plan", "plan", p)
 
 	// start process
 	_, err = db.sql.Exec("PRAGMA foreign_keys=off")
@@ -78,8 +59,26 @@ func (db *DB) Migrate(schema string, allow_deletion bool) error {
 		return zaperr.Wrap(err, "failed to start transaction")
 	}
 
+	_, err = transaction.Exec(migrationsTableSql)
+	if err != nil {
+		transaction.Rollback()
+		return zaperr.Wrap(err, "failed to create schema migrations table",
+			zap.String("statement", migrationsTableSql))
+	}
+
+	// nothing to migrate in fresh database
+	if !p.Fresh {
+		for _, migration := range p.DataMigrations {
+			err := execMigrationStep(transaction, migration, "before", migration.Before)
+			if err != nil {
+				transaction.Rollback()
+				return err
+			}
+		}
+	}
+
 	// create new tables
-	for _, table := range new_tables {
+	for _, table := range p.Tables.Create {
 		zap.S().Infow("create new table",
 			"name", table.Name,
 			"statement", table.Sql)
@@ -93,67 +92,72 @@ func (db *DB) Migrate(schema string, allow_deletion bool) error {
 		}
 	}
 
-	// drop views to prevent errors for "not such table"
+	// drop views and triggers to prevent errors for "not such table"
+	// (trigger of one table can use another one)
 	// they will be restored after tables migration
-	if len(old_tables) > 0 || len(modified_tables) > 0 {
-		views := []Object{}
+	if p.Tables.touchesExisting() {
+		objects := []Object{}
 
 		query := sqlf.From("sqlite_master").
 			Bind(&Object{}).
-			Where("type = ?", "view").
+			Where("type IN (?, ?)", "view", "trigger").
 			Where("sql IS NOT NULL").
 			OrderBy("name")
 
-		err := transaction.Select(&views, query.String(), query.Args()...)
+		err := transaction.Select(&objects, query.String(), query.Args()...)
 		if err != nil {
-			return zaperr.Wrap(err, "failed to get views",
+			transaction.Rollback()
+			return zaperr.Wrap(err, "failed to get views and triggers",
 				zap.String("statement", query.String()),
 				zap.Any("args", query.Args()))
 		}
 
-		for _, view := range views {
-			drop := fmt.Sprintf("DROP VIEW %s", view.Name)
+		for _, object := range objects {
+			drop := fmt.Sprintf("DROP %s %s", strings.ToUpper(object.Kind), object.Name)
 
-			zap.S().Infow("delete view before modifying/deleting tables",
-				"name", view.Name,
+			zap.S().Infow("delete object before modifying/deleting tables",
+				"kind", object.Kind,
+				"name", object.Name,
 				"statement", drop)
 
 			_, err := transaction.Exec(drop)
 			if err != nil {
 				transaction.Rollback()
-				return zaperr.Wrap(err, "failed to drop view before modifying/deleting tables",
-					zap.String("name", view.Name),
+				return zaperr.Wrap(err, "failed to drop object before modifying/deleting tables",
+					zap.String("kind", object.Kind),
+					zap.String("name", object.Name),
 					zap.String("statement", drop))
 			}
 		}
 	}
 
+	for _, table := range p.Tables.Skip {
+		zap.S().Infow("deletion is not allowed; skip table",
+			"name", table.Name,
+			"statement", table.Sql,
+			"lost columns", table.Lost)
+	}
+
 	// delete tables
-	for _, table := range old_tables {
-		if allow_deletion {
-			query := fmt.Sprintf("DROP TABLE %s", table.Name)
+	for _, table := range p.Tables.Drop {
+		query := fmt.Sprintf("DROP TABLE %s", table.Name)
 
-			zap.S().Infow("delete old table",
-				"name", table.Name,
-				"statement", query)
+		zap.S().Infow("delete old table",
+			"name", table.Name,
+			"statement", query)
 
-			_, err := transaction.Exec(query)
-			if err != nil {
-				transaction.Rollback()
-				return zaperr.Wrap(err, "failed to delete old table",
-					zap.String("name", table.Name),
-					zap.String("statement", query))
-			}
-		} else {
-			zap.S().Infow("deletion is not allowed; skip removed table",
-				"name", table.Name,
-				"statement", table.Sql)
+		_, err := transaction.Exec(query)
+		if err != nil {
+			transaction.Rollback()
+			return zaperr.Wrap(err, "failed to delete old table",
+				zap.String("name", table.Name),
+				zap.String("statement", query))
 		}
 	}
 
 	// modify tables
-	for i := range modified_tables {
-		err := migrateModified(transaction, desired, modified_tables[i], allow_deletion)
+	for i := range p.Tables.Rebuild {
+		err := migrateModified(transaction, p.Tables.Rebuild[i])
 		if err != nil {
 			transaction.Rollback()
 			return err
@@ -179,6 +183,22 @@ func (db *DB) Migrate(schema string, allow_deletion bool) error {
 		return err
 	}
 
+	for _, migration := range p.DataMigrations {
+		if !p.Fresh {
+			err := execMigrationStep(transaction, migration, "after", migration.After)
+			if err != nil {
+				transaction.Rollback()
+				return err
+			}
+		}
+
+		err := recordMigration(transaction, migration)
+		if err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
 	err = transaction.Commit()
 	if err != nil {
 		return zaperr.Wrap(err, "failed to commit transaction")
@@ -193,53 +213,7 @@ func (db *DB) Migrate(schema string, allow_deletion bool) error {
 }
 
 // procedure: https://www.sqlite.org/lang_altertable.html#otheralter
-func migrateModified(transaction *sqlx.Tx, desired *sqlx.DB, table Object, allow_deletion bool) error {
-	// check if no removing is meant
-	actual_cols := []Column{}
-	desired_cols := []Column{}
-
-	query := sqlf.From(fmt.Sprintf("pragma_table_info('%s')", table.Name)).
-		Bind(&Column{}).
-		OrderBy("name")
-
-	err := transaction.Select(&actual_cols, query.String())
-	if err != nil {
-		return zaperr.Wrap(err, "failed to get actual table info",
-			zap.String("name", table.Name),
-			zap.String("statement", query.String()))
-	}
-	err = desired.Select(&desired_cols, query.String())
-	if err != nil {
-		return zaperr.Wrap(err, "failed to get desired table info",
-			zap.String("name", table.Name),
-			zap.String("statement", query.String()))
-	}
-
-	left_cols := []Column{}
-	i, j := 0, 0
-	for i < len(desired_cols) && j < len(actual_cols) {
-		if desired_cols[i].Name < actual_cols[j].Name {
-			i++
-			continue
-		}
-		if desired_cols[i].Name > actual_cols[j].Name {
-			j++
-			continue
-		}
-
-		left_cols = append(left_cols, actual_cols[j])
-		i, j = i+1, j+1
-	}
-
-	if len(left_cols) < len(actual_cols) && !allow_deletion {
-		zap.S().Infow("deletion is not allowed; skip modified table with removed cols",
-			"name", table.Name,
-			"statement", table.Sql,
-			"actual columns", actual_cols,
-			"desired columns", desired_cols)
-		return nil
-	}
-
+func migrateModified(transaction *sqlx.Tx, table TablePlan) error {
 	// create new table
 	new_name := fmt.Sprintf("%s_migration", table.Name)
 	create_rename := regexp.MustCompile(fmt.Sprintf(`\b%s\b`, table.Name)).
@@ -250,7 +224,7 @@ func migrateModified(transaction *sqlx.Tx, desired *sqlx.DB, table Object, allow
 		"new_name", new_name,
 		"statement", create_rename)
 
-	_, err = transaction.Exec(create_rename)
+	_, err := transaction.Exec(create_rename)
 	if err != nil {
 		return zaperr.Wrap(err, "failed to create renamed table",
 			zap.String("name", table.Name),
@@ -260,11 +234,11 @@ func migrateModified(transaction *sqlx.Tx, desired *sqlx.DB, table Object, allow
 
 	// migrate data
 	cols := []string{}
-	for _, col := range left_cols {
-		if keyword, ok := keywords[strings.ToLower(col.Name)]; ok {
-			col.Name = keyword
+	for _, col := range table.Copied {
+		if keyword, ok := keywords[strings.ToLower(col)]; ok {
+			col = keyword
 		}
-		cols = append(cols, col.Name)
+		cols = append(cols, col)
 	}
 	list := strings.Join(cols, ",")
 
@@ -281,8 +255,8 @@ func migrateModified(transaction *sqlx.Tx, desired *sqlx.DB, table Object, allow
 			zap.String("name", table.Name),
 			zap.String("new_name", new_name),
 			zap.String("statement", insert),
-			zap.Any("actual columns", actual_cols),
-			zap.Any("desired columns", desired_cols))
+			zap.Strings("copied columns", table.Copied),
+			zap.Strings("lost columns", table.Lost))
 	}
 
 	// drop old table
@@ -0,0 +1,328 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/jmoiron/sqlx"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+type selecter interface {
+	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+// table rebuilt by migrateModified
+type TablePlan struct {
+	Object
+
+	Copied []string `json:"copied_columns,omitempty"`
+	Lost   []string `json:"lost_columns,omitempty"`
+}
+
+type ObjectsPlan struct {
+	Create  []Object `json:"create,omitempty"`
+	Drop    []Object `json:"drop,omitempty"`
+	Rebuild []Object `json:"rebuild,omitempty"`
+}
+
+type TablesPlan struct {
+	Create  []Object    `json:"create,omitempty"`
+	Drop    []Object    `json:"drop,omitempty"`
+	Rebuild []TablePlan `json:"rebuild,omitempty"`
+
+	// tables which should be dropped or lose columns,
+	// but deletion is not allowed
+	Skip []TablePlan `json:"skip,omitempty"`
+}
+
+// Report of changes Migrate is going to make.
+type MigrationPlan struct {
+	AllowDeletion bool `json:"allow_deletion"`
+
+	// data migrations are only recorded for database without tables
+	Fresh          bool            `json:"fresh"`
+	DataMigrations []DataMigration `json:"data_migrations,omitempty"`
+
+	Tables   TablesPlan  `json:"tables"`
+	Indices  ObjectsPlan `json:"indices"`
+	Triggers ObjectsPlan `json:"triggers"`
+	Views    ObjectsPlan `json:"views"`
+}
+
+func (p *MigrationPlan) Empty() bool {
+	return !p.Tables.touchesExisting() &&
+		len(p.Tables.Create) == 0 &&
+		len(p.DataMigrations) == 0 &&
+		p.Indices.empty() &&
+		p.Triggers.empty() &&
+		p.Views.empty()
+}
+
+// reports if applying plan with deletion allowed destroys any rows or columns
+func (p *MigrationPlan) LosesData() bool {
+	if len(p.Tables.Drop) > 0 || len(p.Tables.Skip) > 0 {
+		return true
+	}
+
+	for _, table := range p.Tables.Rebuild {
+		if len(table.Lost) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// views are dropped before tables are modified or deleted
+func (p *TablesPlan) touchesExisting() bool {
+	return len(p.Drop) > 0 || len(p.Rebuild) > 0 || len(p.Skip) > 0
+}
+
+func (p *ObjectsPlan) empty() bool {
+	return len(p.Create) == 0 && len(p.Drop) == 0 && len(p.Rebuild) == 0
+}
+
+// Plan computes migration to schema without touching the database.
+func (db *DB) Plan(schema string, allow_deletion bool, migrations []DataMigration) (*MigrationPlan, error) {
+	err := validateMigrations(migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := pristine(schema)
+	if err != nil {
+		return nil, err
+	}
+	defer desired.Close()
+
+	return plan(db.read, desired, allow_deletion, migrations)
+}
+
+func (db *DB) PlanFromFile(filename string, allow_deletion bool, migrations []DataMigration) (*MigrationPlan, error) {
+	schema, err := readSchema(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Plan(schema, allow_deletion, migrations)
+}
+
+func pristine(schema string) (*sqlx.DB, error) {
+	desired, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to open in-memory database")
+	}
+
+	// every connection to :memory: is a new database
+	desired.SetMaxOpenConns(1)
+
+	_, err = desired.Exec(schema)
+	if err != nil {
+		desired.Close()
+		return nil, zaperr.Wrap(err, "failed to execute schema on pristine database",
+			zap.String("schema", schema))
+	}
+
+	return desired, nil
+}
+
+func plan(actual selecter, desired selecter, allow_deletion bool, migrations []DataMigration) (*MigrationPlan, error) {
+	p := &MigrationPlan{
+		AllowDeletion: allow_deletion,
+	}
+
+	fresh, err := isFresh(actual)
+	if err != nil {
+		return nil, err
+	}
+	p.Fresh = fresh
+
+	p.DataMigrations, err = pendingMigrations(actual, migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	actual_tables := []Object{}
+	desired_tables := []Object{}
+
+	// schema migrations table is managed by migrator itself
+	query := sqlf.From("sqlite_schema").
+		Bind(&Object{}).
+		Where("type = 'table'").
+		Where("name NOT IN ('sqlite_sequence', ?)", migrationsTable).
+		OrderBy("name")
+
+	err = actual.Select(&actual_tables, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get actual tables",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	err = desired.Select(&desired_tables, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get desired tables",
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	new_tables, old_tables, modified_tables := getDifference(actual_tables, desired_tables)
+
+	// tables which will disappear with their indices and triggers
+	dropped := map[string]bool{}
+
+	p.Tables.Create = new_tables
+
+	for _, table := range old_tables {
+		if allow_deletion {
+			p.Tables.Drop = append(p.Tables.Drop, table)
+			dropped[table.Name] = true
+		} else {
+			p.Tables.Skip = append(p.Tables.Skip, TablePlan{Object: table})
+		}
+	}
+
+	for _, table := range modified_tables {
+		copied, lost, err := columnsDifference(actual, desired, table.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		rebuild := TablePlan{
+			Object: table,
+			Copied: copied,
+			Lost:   lost,
+		}
+
+		if len(lost) > 0 && !allow_deletion {
+			p.Tables.Skip = append(p.Tables.Skip, rebuild)
+			continue
+		}
+
+		p.Tables.Rebuild = append(p.Tables.Rebuild, rebuild)
+		dropped[table.Name] = true
+	}
+
+	// views and triggers are dropped before tables migration
+	views_dropped := p.Tables.touchesExisting()
+
+	objects := []struct {
+		kind string
+		plan *ObjectsPlan
+	}{
+		{"index", &p.Indices},
+		{"trigger", &p.Triggers},
+		{"view", &p.Views},
+	}
+
+	for _, o := range objects {
+		err := planObjects(o.kind, actual, desired, o.plan, func(object Object) bool {
+			if o.kind == "view" || o.kind == "trigger" {
+				return views_dropped
+			}
+
+			return dropped[object.Table]
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// is_dropped reports if object disappears implicitly during tables migration
+func planObjects(kind string, actual selecter, desired selecter, p *ObjectsPlan, is_dropped func(Object) bool) error {
+	actual_objects := []Object{}
+	desired_objects := []Object{}
+
+	query := sqlf.From("sqlite_master").
+		Bind(&Object{}).
+		Where("type = ?", kind).
+		Where("sql IS NOT NULL").
+		OrderBy("name")
+
+	err := actual.Select(&actual_objects, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to get actual objects",
+			zap.String("kind", kind),
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	err = desired.Select(&desired_objects, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to get desired objects",
+			zap.String("kind", kind),
+			zap.String("statement", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	p.Create, p.Drop, p.Rebuild = getDifference(actual_objects, desired_objects)
+
+	// unchanged objects which are dropped with their tables have to be created again
+	desired_by_name := map[string]Object{}
+	for _, object := range desired_objects {
+		desired_by_name[object.Name] = object
+	}
+
+	touched := map[string]bool{}
+	for _, object := range append(p.Drop, p.Rebuild...) {
+		touched[object.Name] = true
+	}
+
+	for _, object := range actual_objects {
+		if touched[object.Name] || !is_dropped(object) {
+			continue
+		}
+
+		if d, ok := desired_by_name[object.Name]; ok {
+			p.Rebuild = append(p.Rebuild, d)
+		}
+	}
+
+	return nil
+}
+
+// columns of table which are kept and lost after migration
+func columnsDifference(actual selecter, desired selecter, table string) (kept []string, lost []string, err error) {
+	actual_cols := []Column{}
+	desired_cols := []Column{}
+
+	query := sqlf.From(fmt.Sprintf("pragma_table_info('%s')", table)).
+		Bind(&Column{}).
+		OrderBy("name")
+
+	err = actual.Select(&actual_cols, query.String())
+	if err != nil {
+		return nil, nil, zaperr.Wrap(err, "failed to get actual table info",
+			zap.String("name", table),
+			zap.String("statement", query.String()))
+	}
+	err = desired.Select(&desired_cols, query.String())
+	if err != nil {
+		return nil, nil, zaperr.Wrap(err, "failed to get desired table info",
+			zap.String("name", table),
+			zap.String("statement", query.String()))
+	}
+
+	i, j := 0, 0
+	for j < len(actual_cols) {
+		if i >= len(desired_cols) || desired_cols[i].Name > actual_cols[j].Name {
+			lost = append(lost, actual_cols[j].Name)
+			j++
+			continue
+		}
+		if desired_cols[i].Name < actual_cols[j].Name {
+			i++
+			continue
+		}
+
+		kept = append(kept, actual_cols[j].Name)
+		i, j = i+1, j+1
+	}
+
+	return kept, lost, nil
+}
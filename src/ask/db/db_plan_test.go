@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func memory(t *testing.T, schema string) *DB {
+	d, err := NewDB(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	_, err = d.Exec(context.Background(), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func names[T interface{ name() string }](objects []T) []string {
+	result := make([]string, len(objects))
+	for i := range objects {
+		result[i] = objects[i].name()
+	}
+	return result
+}
+
+func (o Object) name() string    { return o.Name }
+func (t TablePlan) name() string { return t.Name }
+
+const actual_schema = `
+CREATE TABLE roles (name TEXT PRIMARY KEY NOT NULL, tag TEXT);
+CREATE TABLE points (vk_id INT NOT NULL, diff INT NOT NULL);
+CREATE INDEX idx_points_vk_id ON points(vk_id);
+CREATE TABLE old (value INT);
+CREATE VIEW points_sum AS SELECT vk_id, SUM(diff) FROM points GROUP BY vk_id;
+`
+
+const desired_schema = `
+CREATE TABLE roles (name TEXT PRIMARY KEY NOT NULL, hashtag TEXT);
+CREATE TABLE points (vk_id INT NOT NULL, diff INT NOT NULL);
+CREATE INDEX idx_points_vk_id ON points(vk_id);
+CREATE TABLE info (vk_id INT PRIMARY KEY NOT NULL);
+CREATE VIEW points_sum AS SELECT vk_id, SUM(diff) FROM points GROUP BY vk_id;
+`
+
+func TestPlanWithDeletion(t *testing.T) {
+	d := memory(t, actual_schema)
+
+	p, err := d.Plan(desired_schema, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(names(p.Tables.Create), []string{"info"}) {
+		t.Errorf("wrong created tables: %v", p.Tables.Create)
+	}
+	if !slices.Equal(names(p.Tables.Drop), []string{"old"}) {
+		t.Errorf("wrong dropped tables: %v", p.Tables.Drop)
+	}
+	if !slices.Equal(names(p.Tables.Rebuild), []string{"roles"}) {
+		t.Fatalf("wrong rebuilt tables: %v", p.Tables.Rebuild)
+	}
+	if !slices.Equal(p.Tables.Rebuild[0].Lost, []string{"tag"}) {
+		t.Errorf("wrong lost columns: %v", p.Tables.Rebuild[0].Lost)
+	}
+	if !slices.Equal(p.Tables.Rebuild[0].Copied, []string{"name"}) {
+		t.Errorf("wrong copied columns: %v", p.Tables.Rebuild[0].Copied)
+	}
+
+	// views are dropped before tables migration
+	if !slices.Equal(names(p.Views.Rebuild), []string{"points_sum"}) {
+		t.Errorf("wrong rebuilt views: %v", p.Views)
+	}
+	if !p.Indices.empty() {
+		t.Errorf("indices should not be touched: %v", p.Indices)
+	}
+}
+
+func TestPlanWithoutDeletion(t *testing.T) {
+	d := memory(t, actual_schema)
+
+	p, err := d.Plan(desired_schema, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Tables.Drop) > 0 || len(p.Tables.Rebuild) > 0 {
+		t.Errorf("tables should not be dropped: %v", p.Tables)
+	}
+	if !slices.Equal(names(p.Tables.Skip), []string{"old", "roles"}) {
+		t.Errorf("wrong skipped tables: %v", p.Tables.Skip)
+	}
+
+	// plan is not applied
+	err = d.Migrate(desired_schema, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err = d.Plan(desired_schema, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Tables.Create) > 0 || len(p.Tables.Skip) != 2 {
+		t.Errorf("wrong plan after migration: %v", p.Tables)
+	}
+}
+
+func TestPlanRebuildsIndicesOfTable(t *testing.T) {
+	d := memory(t, `CREATE TABLE points (vk_id INT NOT NULL);
+CREATE INDEX idx_points_vk_id ON points(vk_id);`)
+
+	p, err := d.Plan(`CREATE TABLE points (vk_id INT NOT NULL, diff INT NOT NULL DEFAULT 0);
+CREATE INDEX idx_points_vk_id ON points(vk_id);`, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(names(p.Tables.Rebuild), []string{"points"}) {
+		t.Fatalf("wrong rebuilt tables: %v", p.Tables)
+	}
+	if !slices.Equal(names(p.Indices.Rebuild), []string{"idx_points_vk_id"}) {
+		t.Errorf("wrong rebuilt indices: %v", p.Indices)
+	}
+}
+
+// trigger of untouched table can use rebuilt one
+func TestMigrateRebuildsTriggersOfOtherTables(t *testing.T) {
+	d := memory(t, `CREATE TABLE points (vk_id INT NOT NULL);
+CREATE TABLE log (vk_id INT NOT NULL);
+CREATE TRIGGER log_points AFTER INSERT ON log BEGIN
+INSERT INTO points (vk_id) VALUES (new.vk_id);
+END;`)
+
+	desired := `CREATE TABLE points (vk_id INT NOT NULL, diff INT NOT NULL DEFAULT 0);
+CREATE TABLE log (vk_id INT NOT NULL);
+CREATE TRIGGER log_points AFTER INSERT ON log BEGIN
+INSERT INTO points (vk_id) VALUES (new.vk_id);
+END;`
+
+	p, err := d.Plan(desired, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(names(p.Triggers.Rebuild), []string{"log_points"}) {
+		t.Errorf("wrong rebuilt triggers: %v", p.Triggers)
+	}
+
+	err = d.Migrate(desired, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = d.Exec(context.Background(), "INSERT INTO log (vk_id) VALUES (10)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = d.Get(context.Background(), &count, "SELECT count(*) FROM points")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("trigger should be restored after migration: %d", count)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/jmoiron/sqlx"
+)
+
+// Queryer is implemented by DB, Tx and Checker.
+// Queries are cancelled with context.
+type Queryer interface {
+	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+
+	// fn is executed in transaction, which is rolled back if fn fails
+	Transaction(ctx context.Context, fn func(q Queryer) error) error
+}
+
+func (db *DB) Transaction(ctx context.Context, fn func(q Queryer) error) error {
+	tx, err := db.sql.BeginTxx(ctx, nil)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to begin new transaction")
+	}
+
+	err = fn(&Tx{tx: tx})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return zaperr.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+type Tx struct {
+	tx *sqlx.Tx
+}
+
+func (tx *Tx) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return tx.tx.SelectContext(ctx, dest, query, args...)
+}
+
+func (tx *Tx) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return tx.tx.GetContext(ctx, dest, query, args...)
+}
+
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.tx.ExecContext(ctx, query, args...)
+}
+
+// nested transaction is a part of outer one
+func (tx *Tx) Transaction(ctx context.Context, fn func(q Queryer) error) error {
+	return fn(tx)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestConcurrentWrites(t *testing.T) {
+	d, err := NewDB(filepath.Join(t.TempDir(), "ask.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var mode string
+	err = d.Get(context.Background(), &mode, "PRAGMA journal_mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Errorf("wrong journal mode: %s", mode)
+	}
+
+	_, err = d.Exec(context.Background(), "CREATE TABLE points (vk_id INT NOT NULL, diff INT NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// read then write in transaction used to fail with database is locked
+	workers := 8
+	writes := 20
+
+	wg := &sync.WaitGroup{}
+	errs := make(chan error, 2*workers*writes)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(vk_id int) {
+			defer wg.Done()
+
+			for i := 0; i < writes; i++ {
+				errs <- d.Transaction(context.Background(), func(q Queryer) error {
+					var sum int
+					err := q.Get(context.Background(), &sum, "SELECT COALESCE(SUM(diff), 0) FROM points WHERE vk_id = ?", vk_id)
+					if err != nil {
+						return err
+					}
+
+					_, err = q.Exec(context.Background(), "INSERT INTO points (vk_id, diff) VALUES (?, ?)", vk_id, sum+1)
+					return err
+				})
+
+				var count int
+				errs <- d.Get(context.Background(), &count, "SELECT count(*) FROM points")
+			}
+		}(w)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int
+	err = d.Get(context.Background(), &count, "SELECT count(*) FROM points")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != workers*writes {
+		t.Errorf("wrong count of rows: %d", count)
+	}
+}
+
+func TestReadPoolIsQueryOnly(t *testing.T) {
+	d, err := NewDB(filepath.Join(t.TempDir(), "ask.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	_, err = d.read.Exec("CREATE TABLE points (vk_id INT)")
+	if err == nil {
+		t.Error("read pool should not write")
+	}
+}
@@ -1,13 +1,10 @@
 package ask
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
 	"time"
-
-	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
-	"go.uber.org/zap"
 )
 
 type DeadlineCause string
@@ -83,24 +80,19 @@ func (u *UnixTime) Scan(value interface{}) error {
 	return errors.New("failed to scan unixTime")
 }
 
+func (u UnixTime) Time() time.Time {
+	return time.Time(u)
+}
+
 type Deadline struct {
 	Member   int64    `db:"member"`
 	Deadline UnixTime `db:"deadline"`
 }
 
-func (a *Ask) Deadline(member int) (Deadline, error) {
-	var deadline Deadline
-
-	// should be at least one record
-	query := sqlf.From("deadlines").
-		Bind(&deadline).
-		Where("member = ?", member)
-
-	err := a.db.Get(&deadline, query.String(), query.Args()...)
+func (a *Ask) Deadline(ctx context.Context, member int) (Deadline, error) {
+	deadline, err := a.storage.Deadline(ctx, member)
 	if err != nil {
-		return Deadline{}, zaperr.Wrap(err, "failed to get deadline for member",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return Deadline{}, err
 	}
 
 	// add timezone
@@ -110,38 +102,27 @@ func (a *Ask) Deadline(member int) (Deadline, error) {
 	return deadline, nil
 }
 
-func (a *Ask) DeadlineJournal(member int) ([]DeadlineEvent, error) {
-	var history []DeadlineEvent
-
-	query := sqlf.From("deadline_journal").
-		Bind(&DeadlineEvent{}).
-		Where("member = ?", member).
-		OrderBy("timestamp DESC")
-
-	err := a.db.Select(&history, query.String(), query.Args()...)
-	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get deadline journal for member",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return history, nil
+func (a *Ask) DeadlineJournal(ctx context.Context, member int) ([]DeadlineEvent, error) {
+	return a.storage.DeadlineJournal(ctx, member)
 }
 
-// TO-DO maybe another way to insert
-func (a *Ask) ChangeDeadline(member int, diff time.Duration, kind DeadlineCause, cause string) error {
-	query := sqlf.InsertInto("deadline").
-		Set("member", member).
-		Set("diff", diff.Seconds()).
-		Set("kind", kind).
-		Set("cause", cause)
+func (a *Ask) ChangeDeadline(ctx context.Context, actor Actor, member int, diff time.Duration, kind DeadlineCause, cause string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.storage.Member(ctx, member)
+		if err != nil {
+			return err
+		}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to insert deadline event",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
+		err = tx.storage.ChangeDeadline(ctx, member, diff, kind, cause)
+		if err != nil {
+			return err
+		}
+
+		after, err := tx.storage.Member(ctx, member)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.audit(ctx, actor, AuditActions.ChangeDeadline, after.VkID, after.Name, before, after)
+	})
 }
@@ -0,0 +1,87 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"errors"
+	"os"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+// tables available for import and export with their rows
+var ExchangeTables = map[string]interface{}{
+	"administration": Administration{},
+	"roles_groups":   RolesGroup{},
+	"roles":          Role{},
+	"members":        MemberRecord{},
+	"points":         Points{},
+	"schedule":       Timeslot{},
+}
+
+func exchangeRow(table string) (interface{}, error) {
+	row, ok := ExchangeTables[table]
+	if !ok {
+		err := errors.New("table is not available for import and export")
+		return nil, zaperr.Wrap(err, "",
+			zap.String("table", table))
+	}
+
+	return row, nil
+}
+
+// Import loads csv or json file into table, nothing is inserted on any invalid row.
+func Import(path string, table string, filename string) error {
+	row, err := exchangeRow(table)
+	if err != nil {
+		return err
+	}
+
+	format, err := db.FormatFromFilename(filename)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to open file",
+			zap.String("filename", filename))
+	}
+	defer file.Close()
+
+	d, err := db.NewDB(path)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to create new db")
+	}
+	defer d.Close()
+
+	return d.Import(file, format, table, row)
+}
+
+// Export saves table into csv or json file.
+func Export(path string, table string, filename string) error {
+	row, err := exchangeRow(table)
+	if err != nil {
+		return err
+	}
+
+	format, err := db.FormatFromFilename(filename)
+	if err != nil {
+		return err
+	}
+
+	d, err := db.NewReadOnlyDB(path)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to open db read only")
+	}
+	defer d.Close()
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to create file",
+			zap.String("filename", filename))
+	}
+	defer file.Close()
+
+	return d.Export(file, format, table, row)
+}
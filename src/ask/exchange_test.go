@@ -0,0 +1,107 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+const roles_csv = `name,hashtag,shown_name,accusative_name,caption_name,group,order
+alice,#alice,Alice,Alice,Alice,,1
+bob,#bob,Bob,Bob,Bob,,
+`
+
+func TestExchangeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	d := memoryDB(t)
+	s := NewSQLite(d)
+
+	err := d.Import(strings.NewReader(roles_csv), db.Formats.CSV, "roles", ExchangeTables["roles"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	role, err := s.Role(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Order.Int32 != 1 || role.Group.Valid {
+		t.Errorf("wrong imported role: %v", role)
+	}
+
+	members := `[{"vk_id": 10, "role": "alice", "status": "Freeze"}]`
+	err = d.Import(strings.NewReader(members), db.Formats.JSON, "members", ExchangeTables["members"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := &bytes.Buffer{}
+	err = d.Export(buffer, db.Formats.CSV, "members", ExchangeTables["members"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "id,vk_id,role,status\n1,10,alice,Freeze\n" {
+		t.Errorf("wrong exported members: %q", buffer.String())
+	}
+
+	buffer.Reset()
+	err = d.Export(buffer, db.Formats.JSON, "roles", ExchangeTables["roles"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// export of one database is import of another
+	t.Run("Reimport", func(t *testing.T) {
+		other := memoryDB(t)
+
+		err := other.Import(buffer, db.Formats.JSON, "roles", ExchangeTables["roles"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		roles, err := NewSQLite(other).Roles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roles) != 2 || roles[0].Order.Int32 != 1 || roles[1].Order.Valid {
+			t.Errorf("wrong reimported roles: %v", roles)
+		}
+	})
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+
+	d := memoryDB(t)
+	s := NewSQLite(d)
+
+	err := d.Import(strings.NewReader(roles_csv), db.Formats.CSV, "roles", ExchangeTables["roles"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// invalid status is rejected by MemberStatus.Scan
+	members := "vk_id,role,status\n10,alice,Active\n20,bob,Sleeping\n"
+	err = d.Import(strings.NewReader(members), db.Formats.CSV, "members", ExchangeTables["members"])
+	if err == nil {
+		t.Fatal("invalid status should not be imported")
+	}
+
+	// unknown role is rejected by foreign key
+	members = "vk_id,role\n10,alice\n20,nobody\n"
+	err = d.Import(strings.NewReader(members), db.Formats.CSV, "members", ExchangeTables["members"])
+	if err == nil {
+		t.Fatal("unknown role should not be imported")
+	}
+
+	result, err := s.MembersByVkID(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 0 {
+		t.Errorf("members should not be imported partially: %v", result)
+	}
+}
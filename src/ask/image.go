@@ -0,0 +1,112 @@
+package ask
+
+import (
+	"ask-bot/src/ask/images"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type Image struct {
+	Url         string    `db:"url"`
+	Hash        string    `db:"hash"`
+	ContentType string    `db:"content_type"`
+	Size        int64     `db:"size"`
+	Timestamp   time.Time `db:"timestamp"`
+}
+
+// images are not saved locally without store
+func (a *Ask) InitImages(dir string) error {
+	store, err := images.New(dir)
+	if err != nil {
+		return err
+	}
+
+	a.images = store
+	return nil
+}
+
+// SaveImages downloads images into store while their urls are still alive,
+// already saved urls are skipped. Failed download does not stop others,
+// it is retried by SaveGreetingImages.
+func (a *Ask) SaveImages(ctx context.Context, urls Urls) error {
+	if a.images == nil {
+		return nil
+	}
+
+	for _, url := range urls {
+		image, err := a.storage.ImageByUrl(ctx, url)
+		if err != nil {
+			return err
+		}
+		if image != nil {
+			continue
+		}
+
+		file, err := a.images.Download(ctx, url)
+		if err != nil {
+			zap.S().Warnw("failed to download image; it will be retried",
+				"error", err,
+				"url", url)
+			continue
+		}
+
+		err = a.storage.AddImage(ctx, Image{
+			Url:         url,
+			Hash:        file.Hash,
+			ContentType: file.ContentType,
+			Size:        file.Size,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// greetings of reservations which are not saved yet are downloaded again
+func (a *Ask) SaveGreetingImages(ctx context.Context) error {
+	if a.images == nil {
+		return nil
+	}
+
+	reservations, err := a.storage.Reservations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range reservations {
+		err := a.SaveImages(ctx, reservations[i].Greeting)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// OpenImage reads image from store, image which is not saved
+// (e.g. it was sent before store) is downloaded by url.
+func (a *Ask) OpenImage(ctx context.Context, url string) (io.ReadCloser, error) {
+	if a.images != nil {
+		image, err := a.storage.ImageByUrl(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+
+		if image != nil {
+			return a.images.Open(image.Hash)
+		}
+	}
+
+	response, err := images.Fetch(ctx, http.DefaultClient, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return response.Body, nil
+}
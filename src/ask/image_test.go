@@ -0,0 +1,125 @@
+package ask
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGreetingImagesAreSaved(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	a := NewWithStorage(&Config{
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.InitImages(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 10, "alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	greeting := Urls{server.URL + "/first.png", server.URL + "/second.png"}
+
+	err = a.CompleteReservation(ctx, UserActor(10), 10, "alice", greeting)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// vk link is expired
+	server.Close()
+
+	for _, url := range greeting {
+		file, err := a.OpenImage(ctx, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != url[len(server.URL):] {
+			t.Errorf("wrong image content of %s: %q", url, content)
+		}
+	}
+
+	_, err = a.OpenImage(ctx, server.URL+"/unknown.png")
+	if err == nil {
+		t.Error("unknown image should be downloaded")
+	}
+}
+
+func TestGreetingImagesAreRetried(t *testing.T) {
+	ctx := context.Background()
+
+	// cdn is flaky at first
+	flaky := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if flaky {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.InitImages(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 10, "alice", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := server.URL + "/first.png"
+
+	err = a.CompleteReservation(ctx, UserActor(10), 10, "alice", Urls{url})
+	if err != nil {
+		t.Fatalf("failed download should not stop submission: %v", err)
+	}
+
+	image, err := a.storage.ImageByUrl(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image != nil {
+		t.Fatalf("image should not be saved: %v", image)
+	}
+
+	flaky = false
+
+	err = a.SaveGreetingImages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image, err = a.storage.ImageByUrl(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image == nil {
+		t.Error("image should be saved by retry")
+	}
+}
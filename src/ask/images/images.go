@@ -0,0 +1,129 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+// Store keeps downloaded images in dir, every file is named by sha256
+// of its content, so the same image is saved once.
+type Store struct {
+	dir    string
+	client *http.Client
+}
+
+// File is what is known about saved image.
+type File struct {
+	Hash        string
+	ContentType string
+	Size        int64
+}
+
+func New(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to create images directory",
+			zap.String("dir", dir))
+	}
+
+	return &Store{
+		dir:    dir,
+		client: http.DefaultClient,
+	}, nil
+}
+
+func (s *Store) Path(hash string) string {
+	return filepath.Join(s.dir, hash)
+}
+
+// Download saves image by url into store.
+func (s *Store) Download(ctx context.Context, url string) (*File, error) {
+	response, err := Fetch(ctx, s.client, url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// file is renamed to its hash after it is fully written
+	temp, err := os.CreateTemp(s.dir, "download_*")
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to create temp image file",
+			zap.String("dir", s.dir))
+	}
+	defer os.Remove(temp.Name())
+
+	hash := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(temp, hash), response.Body)
+	if err != nil {
+		temp.Close()
+		return nil, zaperr.Wrap(err, "failed to save image",
+			zap.String("url", url))
+	}
+
+	err = temp.Close()
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to close temp image file",
+			zap.String("filename", temp.Name()))
+	}
+
+	file := &File{
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		ContentType: response.Header.Get("Content-Type"),
+		Size:        size,
+	}
+
+	err = os.Rename(temp.Name(), s.Path(file.Hash))
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to move image to store",
+			zap.String("filename", temp.Name()),
+			zap.String("hash", file.Hash))
+	}
+
+	return file, nil
+}
+
+func (s *Store) Open(hash string) (io.ReadCloser, error) {
+	file, err := os.Open(s.Path(hash))
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to open image",
+			zap.String("hash", hash))
+	}
+
+	return file, nil
+}
+
+// Fetch gets image by url, response body should be closed by caller.
+func Fetch(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to create image request",
+			zap.String("url", url))
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to download image",
+			zap.String("url", url))
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+
+		err := errors.New("unexpected status of image response")
+		return nil, zaperr.Wrap(err, "",
+			zap.String("url", url),
+			zap.Int("status", response.StatusCode))
+	}
+
+	return response, nil
+}
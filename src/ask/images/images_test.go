@@ -0,0 +1,63 @@
+package images
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("greeting"))
+	}))
+	defer server.Close()
+
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := s.Download(context.Background(), server.URL+"/first.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ContentType != "image/png" || first.Size != int64(len("greeting")) {
+		t.Errorf("wrong image: %v", first)
+	}
+
+	// the same content is the same file
+	second, err := s.Download(context.Background(), server.URL+"/second.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Hash != second.Hash {
+		t.Errorf("hashes of the same content differ: %s != %s", first.Hash, second.Hash)
+	}
+
+	file, err := s.Open(first.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "greeting" {
+		t.Errorf("wrong content: %q", content)
+	}
+
+	_, err = s.Download(context.Background(), server.URL+"/missing.png")
+	if err == nil {
+		t.Error("missing image should not be saved")
+	}
+}
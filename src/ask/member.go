@@ -1,13 +1,9 @@
 package ask
 
 import (
+	"context"
 	"database/sql/driver"
 	"errors"
-	"time"
-
-	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
-	"go.uber.org/zap"
 )
 
 type MemberStatus string
@@ -41,73 +37,55 @@ func (s *MemberStatus) Scan(value interface{}) error {
 	return errors.New("failed to scan MemberStatus")
 }
 
+// row of members table
+type MemberRecord struct {
+	Id     int          `db:"id"`
+	VkID   int          `db:"vk_id"`
+	Role   string       `db:"role"`
+	Status MemberStatus `db:"status"`
+}
+
 type Member struct {
 	Id       int          `db:"id"`
 	VkID     int          `db:"vk_id"`
 	Status   MemberStatus `db:"status"`
-	Deadline time.Time    `db:"deadline"`
+	Deadline UnixTime     `db:"deadline"`
 	//Timezone int          `db:"timezone"`
 
 	Role
 }
 
 // TO-DO possible no member
-func (a *Ask) MemberByRole(role string) (Member, error) {
-	var member Member
-
-	query := sqlf.From("members_details").
-		Bind(&Member{}).
-		Where("role = ?", role)
-
-	err := a.db.Get(&member, query.String(), query.Args()...)
-	if err != nil {
-		return Member{}, zaperr.Wrap(err, "failed to get member by role",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return member, nil
+func (a *Ask) MemberByRole(ctx context.Context, role string) (Member, error) {
+	return a.storage.MemberByRole(ctx, role)
 }
 
-func (a *Ask) MembersByVkID(vk_id int) ([]Member, error) {
-	var members []Member
-
-	query := sqlf.From("members_details").
-		Bind(&Member{}).
-		Where("vk_id = ?", vk_id)
-
-	err := a.db.Select(&members, query.String(), query.Args()...)
-	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get members by vk_id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return members, nil
+func (a *Ask) MembersByVkID(ctx context.Context, vk_id int) ([]Member, error) {
+	return a.storage.MembersByVkID(ctx, vk_id)
 }
 
-func (a *Ask) AddMember(vk_id int, role string) error {
-	query := sqlf.InsertInto("members").
-		Set("vk_id", vk_id).
-		Set("role", role)
+// member is not added if deadline is not initialized
+func (a *Ask) AddMember(ctx context.Context, actor Actor, vk_id int, role string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		id, err := tx.storage.AddMember(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
 
-	result, err := a.db.Exec(query.String(), query.Args()...)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to add member",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
+		// init deadline
+		err = tx.storage.ChangeDeadline(ctx, id,
+			tx.config.Deadline,
+			DeadlineCauses.Init,
+			"init deadline")
+		if err != nil {
+			return err
+		}
 
-	member, err := result.LastInsertId()
-	if err != nil {
-		return zaperr.Wrap(err, "failed to get last inserted id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
+		member, err := tx.storage.Member(ctx, id)
+		if err != nil {
+			return err
+		}
 
-	// init deadline
-	return a.ChangeDeadline(int(member),
-		a.config.Deadline,
-		DeadlineCauses.Init,
-		"init deadline")
+		return tx.audit(ctx, actor, AuditActions.AddMember, vk_id, role, nil, member)
+	})
 }
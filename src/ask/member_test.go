@@ -0,0 +1,73 @@
+package ask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAddMemberIsAtomic(t *testing.T) {
+	ctx := context.Background()
+
+	s := sqliteStorage(t, fixture).(*SQLite)
+	a := NewWithStorage(&Config{Deadline: 24 * time.Hour}, s)
+
+	// only deadline init by Ask fails, not the one by trigger
+	_, err := s.db.Exec(ctx, `CREATE TRIGGER fail_init_deadline
+		BEFORE INSERT ON deadline_journal
+		WHEN new.cause = 'init deadline'
+		BEGIN SELECT RAISE(ABORT, 'deadline init failed'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.AddMember(ctx, AdminActor(1), 10, "alice")
+	if err == nil {
+		t.Fatal("member should not be added")
+	}
+
+	members, err := a.MembersByVkID(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 0 {
+		t.Errorf("member is left after failed deadline init: %v", members)
+	}
+
+	records, err := a.AuditByVkID(ctx, 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 0 {
+		t.Errorf("failed operation should not be audited: %v", records)
+	}
+}
+
+func TestWithTxJoinsOuter(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.AddMember(ctx, AdminActor(1), 10, "alice")
+		if err != nil {
+			return err
+		}
+
+		return tx.AddReservation(ctx, UserActor(10), 10, "unknown role", 1000)
+	})
+	if err == nil {
+		t.Fatal("reservation on unknown role should fail")
+	}
+
+	members, err := a.MembersByVkID(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 0 {
+		t.Errorf("nested operation should be rolled back with outer one: %v", members)
+	}
+}
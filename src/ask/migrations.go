@@ -0,0 +1,35 @@
+package ask
+
+import "ask-bot/src/ask/db"
+
+// Data migrations are applied by version order together with schema.sql migration.
+// Applied migration should never be changed, add a new one instead.
+//
+// Example of renaming column "tag" of roles to "hashtag":
+//
+//	{
+//		Version: 1,
+//		Name:    "rename roles tag to hashtag",
+//		Before:  "CREATE TEMP TABLE roles_tags AS SELECT name, tag FROM roles",
+//		After: `UPDATE roles SET hashtag = (SELECT tag FROM roles_tags WHERE roles_tags.name = roles.name);
+//			DROP TABLE roles_tags`,
+//	},
+var migrations = []db.DataMigration{
+	{
+		Version: 1,
+		Name:    "accept greetings sent before review",
+		After:   "UPDATE reservations SET is_greeting_accepted = 1 WHERE greeting IS NOT NULL",
+	},
+	{
+		Version: 2,
+		Name:    "start reservations history from current and archived reservations",
+		// history is new here, rows of previous migrations are replaced
+		After: `DELETE FROM reservations_history;
+			INSERT INTO reservations_history (vk_id, role, status, timestamp)
+			SELECT vk_id, role, status, timestamp FROM (
+				SELECT vk_id, role, status, timestamp FROM reservations
+				UNION ALL
+				SELECT vk_id, role, outcome, timestamp FROM reservations_archive)
+			ORDER BY unixepoch(timestamp)`,
+	},
+}
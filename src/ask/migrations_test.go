@@ -0,0 +1,55 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// database of the first released schema is upgraded with its data
+func TestMigrationsFromBaseline(t *testing.T) {
+	ctx := context.Background()
+
+	for _, allow_deletion := range []bool{false, true} {
+		name := strings.ReplaceAll(fmt.Sprintf("%s_%v", t.Name(), allow_deletion), "/", "_")
+
+		d, err := db.NewDB(fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer d.Close()
+
+		err = d.Init("testdata/baseline_schema.sql", allow_deletion, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, statement := range []string{
+			`INSERT INTO roles (name, hashtag, shown_name, accusative_name, caption_name)
+				VALUES ('alice', '#alice', 'Alice', 'Alice', 'Alice')`,
+			`INSERT INTO reservations (vk_id, role, introduction, is_confirmed, deadline)
+				VALUES (10, 'alice', 1, 1, '2024-01-01 00:00:00')`,
+		} {
+			_, err = d.Exec(ctx, statement)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err = d.Init("../../schema.sql", allow_deletion, migrations)
+		if err != nil {
+			t.Fatalf("deletion %v: %v", allow_deletion, err)
+		}
+
+		var roles []string
+		err = d.Select(ctx, &roles, "SELECT role FROM reservations WHERE vk_id = 10")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roles) != 1 || roles[0] != "alice" {
+			t.Errorf("reservation should be kept: %v", roles)
+		}
+	}
+}
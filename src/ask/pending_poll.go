@@ -1,21 +1,23 @@
 package ask
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"strconv"
 	"strings"
-
-	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
-	"go.uber.org/zap"
 )
 
 type VkIDs []int
 
 func (ids VkIDs) Value() (driver.Value, error) {
-	return []int(ids), nil
+	strs := make([]string, len(ids))
+	for i := range ids {
+		strs[i] = strconv.Itoa(ids[i])
+	}
+
+	return strings.Join(strs, ","), nil
 }
 
 func (ids *VkIDs) Scan(value interface{}) error {
@@ -25,6 +27,11 @@ func (ids *VkIDs) Scan(value interface{}) error {
 
 	if str, err := driver.String.ConvertValue(value); err == nil {
 		if v, ok := str.(string); ok {
+			if len(v) == 0 {
+				*ids = VkIDs{}
+				return nil
+			}
+
 			strs := strings.Split(v, ",")
 			var ints []int
 
@@ -83,29 +90,19 @@ type PendingPoll struct {
 	Count        int       `db:"count"`
 	Participants VkIDs     `db:"participants"`
 	Greetings    Greetings `db:"greetings"`
-}
-
-func (a *Ask) PendingPolls() ([]PendingPoll, error) {
-	var polls []PendingPoll
 
-	query := sqlf.From("pending_polls_details").
-		Bind(&PendingPoll{}).
-		OrderBy("name")
-
-	err := a.db.Select(&polls, query.String(), query.Args()...)
-	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get pending polls",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return polls, nil
+	// tied candidates of the previous poll
+	Runoff bool
 }
 
 // value -
 // 1) vk id
 // 2) -1 as no/no one
 // 3) -2 as neutral (really want it a 0 but i should check for undefined value)
+const (
+	NoOnePollAnswer   = -1
+	NeutralPollAnswer = -2
+)
 
 // ID is for vk poll answer id
 type PollAnswer struct {
@@ -114,16 +111,19 @@ type PollAnswer struct {
 	Value int
 }
 
-// return answers with values
-func (poll *PendingPoll) Answers() []PollAnswer {
-	// TO-DO plus if neutral variant
+// return answers with values, neutral answer is the last one
+func (poll *PendingPoll) Answers(neutral bool) []PollAnswer {
 	answers := make([]PollAnswer, poll.Count+1)
 
 	for i := range poll.Participants {
 		answers[i].Value = poll.Participants[i]
 	}
 
-	answers[len(answers)-1].Value = -1
+	answers[len(answers)-1].Value = NoOnePollAnswer
+
+	if neutral {
+		answers = append(answers, PollAnswer{Value: NeutralPollAnswer})
+	}
 
 	return answers
 }
@@ -134,40 +134,31 @@ type PollAnswerCache struct {
 	Value    int `db:"value"`
 }
 
-func (a *Ask) SavePollAnswers(poll_id int, answers []PollAnswer) error {
-	query := sqlf.InsertInto("poll_answer_cache")
-
-	for _, answer := range answers {
-		query.NewRow().
-			Set("poll_id", poll_id).
-			Set("answer_id", answer.ID).
-			Set("value", answer.Value)
+func (a *Ask) PendingPolls(ctx context.Context) ([]PendingPoll, error) {
+	polls, err := a.storage.PendingPolls(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to save poll answers",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+	for i := range polls {
+		polls[i].Runoff, err = a.isRunoff(ctx, polls[i].Name)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return polls, nil
 }
 
-func (a *Ask) LoadPollAnswer(poll_id int, answer_id int) (int, error) {
-	var value int
-
-	query := sqlf.From("poll_answer_cache").
-		Bind(&PollAnswerCache{}).
-		Where("poll_id = ?", poll_id).
-		Where("answer_id = ?", answer_id)
+func (a *Ask) SavePollAnswers(ctx context.Context, poll_id int, answers []PollAnswer) error {
+	return a.storage.SavePollAnswers(ctx, poll_id, answers)
+}
 
-	err := a.db.Get(&value, query.String(), query.Args()...)
-	if err != nil {
-		return 0, zaperr.Wrap(err, "failed to load poll answer",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
+func (a *Ask) DeletePollAnswers(ctx context.Context, poll_id int) error {
+	return a.storage.DeletePollAnswers(ctx, poll_id)
+}
 
-	return value, nil
+// false is returned if answer is not cached
+func (a *Ask) LoadPollAnswer(ctx context.Context, poll_id int, answer_id int) (int, bool, error) {
+	return a.storage.LoadPollAnswer(ctx, poll_id, answer_id)
 }
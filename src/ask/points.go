@@ -1,11 +1,8 @@
 package ask
 
 import (
+	"context"
 	"time"
-
-	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
-	"go.uber.org/zap"
 )
 
 type Points struct {
@@ -15,38 +12,10 @@ type Points struct {
 	Timestamp time.Time `db:"timestamp"`
 }
 
-func (a *Ask) PointsByVkID(vk_id int) (int, error) {
-	var points int
-
-	// zero is default value, it is not a error if it is null
-	query := sqlf.From("points").
-		Select("COALESCE(SUM(diff), 0)").
-		Where("vk_id = ?", vk_id)
-
-	err := a.db.Get(&points, query.String(), query.Args()...)
-	if err != nil {
-		return -1, zaperr.Wrap(err, "failed to get points by vk id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return points, nil
+func (a *Ask) PointsByVkID(ctx context.Context, vk_id int) (int, error) {
+	return a.storage.PointsByVkID(ctx, vk_id)
 }
 
-func (a *Ask) HistoryPointsByVkID(vk_id int) ([]Points, error) {
-	var history []Points
-
-	query := sqlf.From("points").
-		Bind(&Points{}).
-		Where("vk_id = ?", vk_id).
-		OrderBy("timestamp DESC")
-
-	err := a.db.Select(&history, query.String(), query.Args()...)
-	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get history of points by vk id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
-	}
-
-	return history, nil
+func (a *Ask) HistoryPointsByVkID(ctx context.Context, vk_id int) ([]Points, error) {
+	return a.storage.HistoryPointsByVkID(ctx, vk_id)
 }
@@ -1,9 +1,13 @@
 package ask
 
 import (
-	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
-	"go.uber.org/zap"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
 )
 
 type OngoingPoll struct {
@@ -11,55 +15,352 @@ type OngoingPoll struct {
 	Post int    `db:"post"`
 }
 
-func (a *Ask) OngoingPolls() ([]OngoingPoll, error) {
-	var polls []OngoingPoll
+type Poll struct {
+	PendingPoll
+
+	// null for pending polls
+	Post sql.NullInt32 `db:"post"`
+}
+
+func (a *Ask) OngoingPolls(ctx context.Context) ([]OngoingPoll, error) {
+	return a.storage.OngoingPolls(ctx)
+}
 
-	query := sqlf.From("ongoing_polls").
-		Bind(&OngoingPoll{})
+func (a *Ask) AddOngoingPoll(ctx context.Context, actor Actor, role string, post int) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.storage.AddOngoingPoll(ctx, role, post)
+		if err != nil {
+			return err
+		}
 
-	err := a.db.Select(&polls, query.String(), query.Args()...)
+		return tx.audit(ctx, actor, AuditActions.AddOngoingPoll, 0, role, nil, OngoingPoll{
+			Role: role,
+			Post: post,
+		})
+	})
+}
+
+func (a *Ask) Polls(ctx context.Context) ([]Poll, error) {
+	return a.storage.Polls(ctx)
+}
+
+// votes for answer of ended poll, value is the same as of poll answer
+type PollResult struct {
+	Value int
+	Votes int
+}
+
+type PollResults []PollResult
+
+func (r PollResults) Value() (driver.Value, error) {
+	json, err := json.Marshal(r)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get ongoing polls",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
-	return polls, nil
+	return string(json), nil
 }
 
-func (a *Ask) AddOngoingPoll(role string, post int) error {
-	query := sqlf.InsertInto("ongoing_polls").
-		Set("role", role).
-		Set("post", post)
+func (r *PollResults) Scan(value interface{}) error {
+	if value == nil {
+		return errors.New("PollResults is not nullable")
+	}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to add ongoing poll",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+	if str, err := driver.String.ConvertValue(value); err == nil {
+		if v, ok := str.(string); ok {
+			var results []PollResult
+			err := json.Unmarshal([]byte(v), &results)
+			if err != nil {
+				return errors.New("failed to unmarshal poll results")
+			}
+
+			*r = results
+			return nil
+		}
 	}
 
-	return nil
+	return errors.New("failed to scan PollResults")
 }
 
-type Poll struct {
-	PendingPoll
+type PollNoOneRule string
+
+var PollNoOneRules = struct {
+	// reservations of all participants are lost
+	Release PollNoOneRule
+	// answer is not counted, the best candidate wins
+	Ignore PollNoOneRule
+}{
+	Release: "Release",
+	Ignore:  "Ignore",
+}
+
+type PollDecisionKind string
 
-	Post int `db:"post"`
+var PollDecisionKinds = struct {
+	Winner   PollDecisionKind
+	NoOne    PollDecisionKind
+	NoQuorum PollDecisionKind
+	Tie      PollDecisionKind
+	Runoff   PollDecisionKind
+	// winner has no reservation anymore, e.g. cancelled it during poll
+	Withdrawn PollDecisionKind
+}{
+	Winner:    "Winner",
+	NoOne:     "NoOne",
+	NoQuorum:  "NoQuorum",
+	Tie:       "Tie",
+	Runoff:    "Runoff",
+	Withdrawn: "Withdrawn",
 }
 
-func (a *Ask) Polls() ([]Poll, error) {
-	var polls []Poll
+func (k PollDecisionKind) Value() (driver.Value, error) {
+	return string(k), nil
+}
+
+func (k *PollDecisionKind) Scan(value interface{}) error {
+	if value == nil {
+		return errors.New("PollDecisionKind is not nullable")
+	}
+	if str, err := driver.String.ConvertValue(value); err == nil {
+		if v, ok := str.(string); ok {
+			// check if is valid
+			if v != string(PollDecisionKinds.Winner) &&
+				v != string(PollDecisionKinds.NoOne) &&
+				v != string(PollDecisionKinds.NoQuorum) &&
+				v != string(PollDecisionKinds.Tie) &&
+				v != string(PollDecisionKinds.Runoff) &&
+				v != string(PollDecisionKinds.Withdrawn) {
+				return errors.New("value is not valid PollDecisionKind value")
+			}
+			*k = PollDecisionKind(v)
+			return nil
+		}
+	}
+	return errors.New("failed to scan PollDecisionKind")
+}
 
-	query := sqlf.From("polls").
-		Bind(&Poll{})
+// row of poll decisions log, it is archive of ended polls too
+type PollDecision struct {
+	Id           int              `db:"id"`
+	Role         string           `db:"role"`
+	Post         int              `db:"post"`
+	Participants VkIDs            `db:"participants"`
+	Kind         PollDecisionKind `db:"kind"`
+	Winner       sql.NullInt32    `db:"winner"`
+	Results      PollResults      `db:"results"`
+	Timestamp    time.Time        `db:"timestamp"`
+}
+
+// zero if there is no winner
+func (d PollDecision) WinnerID() int {
+	return int(d.Winner.Int32)
+}
+
+type PollOutcome struct {
+	OngoingPoll
+
+	Decision PollDecisionKind
+	// vk id of new member, zero if no one won
+	Winner int
+	// candidates of runoff poll keep their reservations
+	Runoff       []int
+	Participants []int
+	Results      []PollResult
+}
 
-	err := a.db.Select(&polls, query.String(), query.Args()...)
+// Winner is the only answer which leads others by min lead.
+// Tied candidates get runoff if it is allowed and poll is not runoff already.
+// Single candidate tied with "no one" has not beaten it, so no one wins.
+// Neutral votes are not counted at all.
+func (r *PollRules) decide(results []PollResult, is_runoff bool) (PollDecisionKind, int, []int) {
+	results = slices.DeleteFunc(slices.Clone(results), func(result PollResult) bool {
+		return result.Value == NeutralPollAnswer
+	})
+
+	total := 0
+	for _, result := range results {
+		total += result.Votes
+	}
+
+	if total == 0 || total < r.Quorum {
+		return PollDecisionKinds.NoQuorum, 0, nil
+	}
+
+	ranked := slices.Clone(results)
+	if r.NoOne == PollNoOneRules.Ignore {
+		ranked = slices.DeleteFunc(ranked, func(result PollResult) bool {
+			return result.Value == NoOnePollAnswer
+		})
+	}
+	if len(ranked) == 0 {
+		return PollDecisionKinds.NoOne, 0, nil
+	}
+
+	slices.SortStableFunc(ranked, func(a, b PollResult) int {
+		return b.Votes - a.Votes
+	})
+
+	lead := r.MinLead
+	if lead < 1 {
+		lead = 1
+	}
+
+	var contenders []PollResult
+	for _, result := range ranked {
+		if ranked[0].Votes-result.Votes < lead {
+			contenders = append(contenders, result)
+		}
+	}
+
+	if len(contenders) == 1 {
+		if contenders[0].Value == NoOnePollAnswer {
+			return PollDecisionKinds.NoOne, 0, nil
+		}
+
+		return PollDecisionKinds.Winner, contenders[0].Value, nil
+	}
+
+	var candidates []int
+	for _, contender := range contenders {
+		if contender.Value != NoOnePollAnswer {
+			candidates = append(candidates, contender.Value)
+		}
+	}
+
+	// runoff needs at least two candidates
+	if len(candidates) < 2 {
+		return PollDecisionKinds.NoOne, 0, nil
+	}
+
+	if r.Runoff && !is_runoff {
+		return PollDecisionKinds.Runoff, 0, candidates
+	}
+
+	return PollDecisionKinds.Tie, 0, nil
+}
+
+// poll is runoff if the previous poll of role was decided so
+func (a *Ask) isRunoff(ctx context.Context, role string) (bool, error) {
+	last, err := a.storage.LastPollDecision(ctx, role)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get polls",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return false, err
+	}
+
+	return last != nil && last.Kind == PollDecisionKinds.Runoff, nil
+}
+
+// Ended poll is decided by rules of config. Winner becomes member,
+// runoff candidates wait for the next poll, reservations of others are lost.
+// Winner without reservation is withdrawn, so no one wins and the others
+// keep their reservations for the next poll.
+// Poll is not ongoing anymore, decision is saved in log
+// and its answer cache by vk poll id is purged.
+func (a *Ask) EndPoll(ctx context.Context, actor Actor, poll OngoingPoll, poll_id int, results []PollResult) (*PollOutcome, error) {
+	outcome := &PollOutcome{
+		OngoingPoll: poll,
+		Results:     results,
 	}
 
-	return polls, nil
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		is_runoff, err := tx.isRunoff(ctx, poll.Role)
+		if err != nil {
+			return err
+		}
+
+		outcome.Decision, outcome.Winner, outcome.Runoff = tx.config.PollRules.decide(results, is_runoff)
+
+		reservations, err := tx.storage.ReservationsByRole(ctx, poll.Role)
+		if err != nil {
+			return err
+		}
+
+		for i := range reservations {
+			outcome.Participants = append(outcome.Participants, reservations[i].VkID)
+		}
+
+		// answer could be outdated
+		if outcome.Winner != 0 && !slices.Contains(outcome.Participants, outcome.Winner) {
+			outcome.Decision = PollDecisionKinds.Withdrawn
+			outcome.Winner = 0
+		}
+
+		for i := range reservations {
+			switch {
+			case reservations[i].VkID == outcome.Winner:
+				err = tx.deleteReservation(ctx, actor, AuditActions.DeleteReservation,
+					reservations[i].VkID, poll.Role, ReservationOutcomes.Accepted, "poll is won")
+			case slices.Contains(outcome.Runoff, reservations[i].VkID),
+				outcome.Decision == PollDecisionKinds.Withdrawn:
+				continue
+			default:
+				err = tx.deleteReservation(ctx, actor, AuditActions.DeleteReservation,
+					reservations[i].VkID, poll.Role, ReservationOutcomes.Lost, "poll is lost")
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if outcome.Winner != 0 {
+			err = tx.AddMember(ctx, actor, outcome.Winner, poll.Role)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.storage.DeleteOngoingPoll(ctx, poll.Role)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.AddPollDecision(ctx, PollDecision{
+			Role:         poll.Role,
+			Post:         poll.Post,
+			Participants: outcome.Participants,
+			Kind:         outcome.Decision,
+			Winner:       sql.NullInt32{Int32: int32(outcome.Winner), Valid: outcome.Winner != 0},
+			Results:      results,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DeletePollAnswers(ctx, poll_id)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.EndPoll, outcome.Winner, poll.Role, poll, outcome)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outcome, nil
+}
+
+func (a *Ask) PollDecisions(ctx context.Context, limit int) ([]PollDecision, error) {
+	decisions, err := a.storage.PollDecisions(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range decisions {
+		decisions[i].Timestamp = decisions[i].Timestamp.Add(a.timezone)
+	}
+
+	return decisions, nil
+}
+
+// Poll without post could not be ended, so it is dropped and its
+// participants wait for the new one.
+func (a *Ask) DropPoll(ctx context.Context, actor Actor, poll OngoingPoll) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.storage.DeleteOngoingPoll(ctx, poll.Role)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.DropPoll, 0, poll.Role, poll, nil)
+	})
 }
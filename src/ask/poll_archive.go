@@ -0,0 +1,24 @@
+package ask
+
+import (
+	"context"
+)
+
+// roles with ended polls filtered by prefix of shown name
+func (a *Ask) PollsArchiveRoles(ctx context.Context, prefix string) ([]Role, error) {
+	return a.storage.PollDecisionsRoles(ctx, prefix)
+}
+
+// ended polls of role are taken from decisions log, latest go first
+func (a *Ask) PollsArchive(ctx context.Context, role string, limit int) ([]PollDecision, error) {
+	decisions, err := a.storage.PollDecisionsByRole(ctx, role, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range decisions {
+		decisions[i].Timestamp = decisions[i].Timestamp.Add(a.timezone)
+	}
+
+	return decisions, nil
+}
@@ -0,0 +1,333 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDecidePoll(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     PollRules
+		is_runoff bool
+		results   []PollResult
+		decision  PollDecisionKind
+		winner    int
+		runoff    []int
+	}{
+		{"most votes", PollRules{}, false, []PollResult{{10, 3}, {20, 5}, {-1, 1}}, PollDecisionKinds.Winner, 20, nil},
+		{"tie", PollRules{}, false, []PollResult{{10, 5}, {20, 5}, {-1, 1}}, PollDecisionKinds.Tie, 0, nil},
+		{"no one", PollRules{}, false, []PollResult{{10, 1}, {20, 2}, {-1, 4}}, PollDecisionKinds.NoOne, 0, nil},
+		{"no votes", PollRules{}, false, []PollResult{{10, 0}, {-1, 0}}, PollDecisionKinds.NoQuorum, 0, nil},
+
+		{"quorum", PollRules{Quorum: 10}, false, []PollResult{{10, 5}, {-1, 1}}, PollDecisionKinds.NoQuorum, 0, nil},
+		{"min lead", PollRules{MinLead: 3}, false, []PollResult{{10, 5}, {20, 3}, {-1, 0}}, PollDecisionKinds.Tie, 0, nil},
+		{"ignored no one", PollRules{NoOne: PollNoOneRules.Ignore}, false, []PollResult{{10, 1}, {20, 2}, {-1, 4}}, PollDecisionKinds.Winner, 20, nil},
+		{"runoff", PollRules{Runoff: true}, false, []PollResult{{10, 5}, {20, 5}, {30, 1}, {-1, 1}}, PollDecisionKinds.Runoff, 0, []int{10, 20}},
+		{"runoff with no one", PollRules{Runoff: true}, false, []PollResult{{10, 5}, {20, 5}, {30, 1}, {-1, 5}}, PollDecisionKinds.Runoff, 0, []int{10, 20}},
+		{"candidate tied with no one", PollRules{}, false, []PollResult{{10, 5}, {20, 1}, {-1, 5}}, PollDecisionKinds.NoOne, 0, nil},
+		{"no runoff of single candidate", PollRules{Runoff: true}, false, []PollResult{{10, 5}, {20, 1}, {-1, 5}}, PollDecisionKinds.NoOne, 0, nil},
+		{"tie of runoff", PollRules{Runoff: true}, true, []PollResult{{10, 5}, {20, 5}, {-1, 1}}, PollDecisionKinds.Tie, 0, nil},
+
+		{"neutral", PollRules{}, false, []PollResult{{10, 3}, {20, 2}, {-1, 1}, {-2, 10}}, PollDecisionKinds.Winner, 10, nil},
+		{"only neutral", PollRules{}, false, []PollResult{{10, 0}, {-1, 0}, {-2, 10}}, PollDecisionKinds.NoQuorum, 0, nil},
+		{"neutral and quorum", PollRules{Quorum: 5}, false, []PollResult{{10, 3}, {-1, 0}, {-2, 10}}, PollDecisionKinds.NoQuorum, 0, nil},
+	}
+
+	for _, test := range tests {
+		decision, winner, runoff := test.rules.decide(test.results, test.is_runoff)
+		if decision != test.decision || winner != test.winner || !slices.Equal(runoff, test.runoff) {
+			t.Errorf("%s: expected %s %d %v, got %s %d %v", test.name,
+				test.decision, test.winner, test.runoff,
+				decision, winner, runoff)
+		}
+	}
+}
+
+func TestPollDuration(t *testing.T) {
+	a := NewWithStorage(&Config{
+		PollDuration:       24 * time.Hour,
+		GroupPollDurations: map[string]time.Duration{"fandom": 72 * time.Hour},
+	}, sqliteStorage(t, fixture))
+
+	tests := []struct {
+		group    sql.NullString
+		duration time.Duration
+	}{
+		{sql.NullString{}, 24 * time.Hour},
+		{sql.NullString{String: "other", Valid: true}, 24 * time.Hour},
+		{sql.NullString{String: "fandom", Valid: true}, 72 * time.Hour},
+	}
+
+	for _, test := range tests {
+		duration := a.PollDuration(Role{Group: test.group})
+		if duration != test.duration {
+			t.Errorf("group %v: expected %s, got %s", test.group, test.duration, duration)
+		}
+	}
+}
+
+func TestEndPoll(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            7 * 24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	for _, vk_id := range []int{10, 20} {
+		err := a.storage.AddReservation(ctx, vk_id, "alice", 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.storage.CompleteReservation(ctx, vk_id, "alice", Urls{}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	poll := OngoingPoll{Role: "alice", Post: 500}
+	err := a.AddOngoingPoll(ctx, WatcherActor, poll.Role, poll.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.SavePollAnswers(ctx, 7, []PollAnswer{{ID: 1, Value: 10}, {ID: 2, Value: 20}, {ID: 3, Value: -1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outcome, err := a.EndPoll(ctx, WatcherActor, poll, 7, []PollResult{{10, 2}, {20, 5}, {-1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Winner != 20 || len(outcome.Participants) != 2 {
+		t.Errorf("wrong outcome: %v", outcome)
+	}
+
+	member, err := a.MemberByRole(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.VkID != 20 {
+		t.Errorf("winner should become member: %v", member)
+	}
+
+	ongoing, err := a.OngoingPolls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ongoing) != 0 {
+		t.Errorf("ended poll should not be ongoing: %v", ongoing)
+	}
+
+	for vk_id, outcome := range map[int]ReservationOutcome{
+		10: ReservationOutcomes.Lost,
+		20: ReservationOutcomes.Accepted,
+	} {
+		archived, err := a.storage.ArchivedReservations(ctx, vk_id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archived) != 1 || archived[0].Outcome != outcome {
+			t.Errorf("wrong archived reservation of %d: %v", vk_id, archived)
+		}
+	}
+
+	polls, err := a.PollsArchive(ctx, "alice", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(polls) != 1 || polls[0].Post != 500 || polls[0].WinnerID() != 20 ||
+		!slices.Equal(polls[0].Participants, VkIDs{10, 20}) || len(polls[0].Results) != 3 {
+		t.Errorf("wrong polls archive: %v", polls)
+	}
+
+	// answer cache of ended poll is purged
+	_, ok, err := a.LoadPollAnswer(ctx, 7, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Errorf("answer cache should be purged")
+	}
+}
+
+// winner cancelled reservation during poll
+func TestEndPollWithdrawn(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            7 * 24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	for _, vk_id := range []int{10, 20} {
+		err := a.storage.AddReservation(ctx, vk_id, "alice", 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.storage.CompleteReservation(ctx, vk_id, "alice", Urls{}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	poll := OngoingPoll{Role: "alice", Post: 500}
+	err := a.AddOngoingPoll(ctx, WatcherActor, poll.Role, poll.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.storage.DeleteReservation(ctx, 20, "alice", ReservationOutcomes.Cancelled, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outcome, err := a.EndPoll(ctx, WatcherActor, poll, 7, []PollResult{{10, 2}, {20, 5}, {-1, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Decision != PollDecisionKinds.Withdrawn || outcome.Winner != 0 {
+		t.Errorf("withdrawn winner should not win: %v", outcome)
+	}
+
+	member, err := a.MemberByRole(ctx, "alice")
+	if err == nil {
+		t.Errorf("there should be no member: %v", member)
+	}
+
+	// the role is polled again among the rest
+	archived, err := a.storage.ArchivedReservations(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != 0 {
+		t.Errorf("reservation of participant should be kept: %v", archived)
+	}
+
+	pending, err := a.PendingPolls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Name != "alice" || !slices.Equal(pending[0].Participants, VkIDs{10}) {
+		t.Errorf("role should be pending again: %v", pending)
+	}
+
+	decisions, err := a.PollDecisions(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 1 || decisions[0].Kind != PollDecisionKinds.Withdrawn {
+		t.Errorf("wrong decisions log: %v", decisions)
+	}
+}
+
+func TestDropPoll(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            7 * 24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.storage.AddReservation(ctx, 10, "alice", 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.storage.CompleteReservation(ctx, 10, "alice", Urls{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	poll := OngoingPoll{Role: "alice", Post: 500}
+	err = a.AddOngoingPoll(ctx, WatcherActor, poll.Role, poll.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.DropPoll(ctx, WatcherActor, poll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// participants wait for the new poll
+	pending, err := a.PendingPolls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Name != "alice" {
+		t.Errorf("dropped poll should be pending: %v", pending)
+	}
+}
+
+func TestEndPollRunoff(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		Deadline:            7 * 24 * time.Hour,
+		ReservationDuration: 24 * time.Hour,
+		PollRules:           PollRules{Runoff: true},
+	}, sqliteStorage(t, fixture))
+
+	for _, vk_id := range []int{10, 20, 30} {
+		err := a.storage.AddReservation(ctx, vk_id, "alice", 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.storage.CompleteReservation(ctx, vk_id, "alice", Urls{}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	poll := OngoingPoll{Role: "alice", Post: 500}
+	err := a.AddOngoingPoll(ctx, WatcherActor, poll.Role, poll.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outcome, err := a.EndPoll(ctx, WatcherActor, poll, 7, []PollResult{{10, 4}, {20, 4}, {30, 1}, {-1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Decision != PollDecisionKinds.Runoff || !slices.Equal(outcome.Runoff, []int{10, 20}) {
+		t.Fatalf("wrong outcome: %v", outcome)
+	}
+
+	// runoff candidates are in the next poll
+	pending, err := a.PendingPolls(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || !pending[0].Runoff || !slices.Equal(pending[0].Participants, VkIDs{10, 20}) {
+		t.Fatalf("runoff should be pending: %v", pending)
+	}
+
+	poll.Post = 600
+	err = a.AddOngoingPoll(ctx, WatcherActor, poll.Role, poll.Post)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the second tie has no winner
+	outcome, err = a.EndPoll(ctx, WatcherActor, poll, 8, []PollResult{{10, 3}, {20, 3}, {-1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Decision != PollDecisionKinds.Tie || len(outcome.Runoff) != 0 {
+		t.Errorf("runoff should not be repeated: %v", outcome)
+	}
+
+	decisions, err := a.PollDecisions(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decisions) != 2 || decisions[0].Kind != PollDecisionKinds.Tie || decisions[0].Post != 600 ||
+		decisions[1].Kind != PollDecisionKinds.Runoff || len(decisions[1].Results) != 4 {
+		t.Errorf("wrong decisions log: %v", decisions)
+	}
+}
@@ -0,0 +1,225 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+)
+
+// status is reservation status or outcome of archived reservation
+type ReservationHistoryRecord struct {
+	Id        int            `db:"id"`
+	VkID      int            `db:"vk_id"`
+	Role      string         `db:"role"`
+	Status    string         `db:"status"`
+	Group     sql.NullString `db:"[group]"`
+	Timestamp time.Time      `db:"timestamp"`
+
+	GroupShownName sql.NullString `db:"group_shown_name"`
+}
+
+func (r *ReservationHistoryRecord) isOutcome() bool {
+	return slices.Contains([]ReservationOutcome{
+		ReservationOutcomes.Expired,
+		ReservationOutcomes.Cancelled,
+		ReservationOutcomes.Declined,
+		ReservationOutcomes.Accepted,
+		ReservationOutcomes.Deleted,
+		ReservationOutcomes.Lost,
+	}, ReservationOutcome(r.Status))
+}
+
+// how many reservations reached every stage
+type FunnelCounts struct {
+	Made      int
+	Confirmed int
+	Completed int
+	Polled    int
+	// poll is won and user became member
+	Accepted int
+}
+
+type GroupFunnel struct {
+	// empty for roles without group
+	Group     string
+	ShownName string
+
+	FunnelCounts
+}
+
+// only finished stays in status are counted
+type StatusDuration struct {
+	Status  ReservationStatus
+	Average time.Duration
+	Count   int
+}
+
+func (d StatusDuration) Hours() float64 {
+	return d.Average.Hours()
+}
+
+type Funnel struct {
+	FunnelCounts
+
+	Groups    []GroupFunnel
+	Durations []StatusDuration
+}
+
+var funnelStatuses = []ReservationStatus{
+	ReservationStatuses.UnderConsideration,
+	ReservationStatuses.InProgress,
+	ReservationStatuses.UnderReview,
+	ReservationStatuses.Done,
+	ReservationStatuses.Poll,
+}
+
+// ReservationFunnel is built from reservations history. Records of one user
+// and role are split into reservations by outcomes, as user has one reservation
+// of role at time.
+func (a *Ask) ReservationFunnel(ctx context.Context) (*Funnel, error) {
+	records, err := a.storage.ReservationsHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &Funnel{}
+	groups := map[string]*GroupFunnel{}
+
+	total := map[ReservationStatus]time.Duration{}
+	count := map[ReservationStatus]int{}
+
+	for begin := 0; begin < len(records); {
+		end := begin + 1
+		for end < len(records) &&
+			records[end].VkID == records[begin].VkID &&
+			records[end].Role == records[begin].Role &&
+			!records[end-1].isOutcome() {
+			end++
+		}
+
+		reservation := records[begin:end]
+
+		group, ok := groups[reservation[0].Group.String]
+		if !ok {
+			group = &GroupFunnel{
+				Group:     reservation[0].Group.String,
+				ShownName: reservation[0].GroupShownName.String,
+			}
+			groups[group.Group] = group
+		}
+
+		reached := reservationStages(reservation)
+		funnel.add(reached)
+		group.add(reached)
+
+		for i := 0; i+1 < len(reservation); i++ {
+			status := ReservationStatus(reservation[i].Status)
+			if !slices.Contains(funnelStatuses, status) {
+				continue
+			}
+
+			total[status] += reservation[i+1].Timestamp.Sub(reservation[i].Timestamp)
+			count[status] += 1
+		}
+
+		begin = end
+	}
+
+	for _, group := range groups {
+		funnel.Groups = append(funnel.Groups, *group)
+	}
+	slices.SortFunc(funnel.Groups, func(a, b GroupFunnel) int {
+		return strings.Compare(a.Group, b.Group)
+	})
+
+	for _, status := range funnelStatuses {
+		if count[status] == 0 {
+			continue
+		}
+
+		funnel.Durations = append(funnel.Durations, StatusDuration{
+			Status:  status,
+			Average: total[status] / time.Duration(count[status]),
+			Count:   count[status],
+		})
+	}
+
+	return funnel, nil
+}
+
+func reservationStages(reservation []ReservationHistoryRecord) FunnelCounts {
+	reached := FunnelCounts{Made: 1}
+
+	for _, record := range reservation {
+		switch record.Status {
+		case string(ReservationStatuses.InProgress):
+			reached.Confirmed = 1
+		case string(ReservationStatuses.UnderReview), string(ReservationStatuses.Done):
+			reached.Completed = 1
+		case string(ReservationStatuses.Poll):
+			reached.Polled = 1
+		case string(ReservationOutcomes.Accepted):
+			reached.Accepted = 1
+		}
+	}
+
+	return reached
+}
+
+func (f *FunnelCounts) add(other FunnelCounts) {
+	f.Made += other.Made
+	f.Confirmed += other.Confirmed
+	f.Completed += other.Completed
+	f.Polled += other.Polled
+	f.Accepted += other.Accepted
+}
+
+func (f *FunnelCounts) record(name string) []string {
+	return []string{
+		name,
+		strconv.Itoa(f.Made),
+		strconv.Itoa(f.Confirmed),
+		strconv.Itoa(f.Completed),
+		strconv.Itoa(f.Polled),
+		strconv.Itoa(f.Accepted),
+	}
+}
+
+// WriteCSV writes funnel by groups and then average durations in statuses.
+func (f *Funnel) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	records := [][]string{
+		{"group", "made", "confirmed", "completed", "polled", "accepted"},
+		f.FunnelCounts.record("all"),
+	}
+	for _, group := range f.Groups {
+		records = append(records, group.record(group.Group))
+	}
+
+	records = append(records,
+		[]string{},
+		[]string{"status", "average_hours", "count"})
+	for _, d := range f.Durations {
+		records = append(records, []string{
+			string(d.Status),
+			fmt.Sprintf("%.2f", d.Hours()),
+			strconv.Itoa(d.Count),
+		})
+	}
+
+	err := writer.WriteAll(records)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to write funnel csv")
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package ask
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReservationFunnel(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	steps := []func() error{
+		// the whole way to member
+		func() error { return a.AddReservation(ctx, UserActor(10), 10, "alice", 100) },
+		func() error { _, err := a.ConfirmReservation(ctx, AdminActor(1), 10, "alice"); return err },
+		func() error {
+			return a.CompleteReservation(ctx, UserActor(10), 10, "alice", Urls{"https://example.com/greeting.png"})
+		},
+		func() error { return a.AddOngoingPoll(ctx, WatcherActor, "alice", 1000) },
+		func() error {
+			return a.DeleteReservationByRole(ctx, WatcherActor, "alice", ReservationOutcomes.Accepted, "poll is won")
+		},
+
+		// declined at once
+		func() error { return a.AddReservation(ctx, UserActor(20), 20, "bob", 200) },
+		func() error { _, err := a.DeclineReservation(ctx, AdminActor(1), 20, "bob", ""); return err },
+
+		// the second reservation of the same user
+		func() error { _, err := a.AddConfirmedReservation(ctx, AdminActor(1), 10, "carol", 0); return err },
+	}
+	for _, step := range steps {
+		err := step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	funnel, err := a.ReservationFunnel(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := FunnelCounts{Made: 3, Confirmed: 2, Completed: 1, Polled: 1, Accepted: 1}
+	if funnel.FunnelCounts != expected {
+		t.Errorf("wrong funnel: %+v != %+v", funnel.FunnelCounts, expected)
+	}
+	if len(funnel.Groups) != 1 || funnel.Groups[0].FunnelCounts != expected {
+		t.Errorf("wrong funnel by groups: %+v", funnel.Groups)
+	}
+
+	counts := map[ReservationStatus]int{}
+	for _, d := range funnel.Durations {
+		counts[d.Status] = d.Count
+	}
+	// current status of carol is not finished
+	expected_counts := map[ReservationStatus]int{
+		ReservationStatuses.UnderConsideration: 2,
+		ReservationStatuses.InProgress:         1,
+		ReservationStatuses.Done:               1,
+		ReservationStatuses.Poll:               1,
+	}
+	for status, count := range expected_counts {
+		if counts[status] != count {
+			t.Errorf("wrong count of %s: %d != %d", status, counts[status], count)
+		}
+	}
+
+	var csv strings.Builder
+	err = funnel.WriteCSV(&csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csv.String(), "all,3,2,1,1,1\n") {
+		t.Errorf("wrong csv:\n%s", csv.String())
+	}
+}
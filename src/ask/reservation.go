@@ -1,14 +1,15 @@
 package ask
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
 	"go.uber.org/zap"
 )
 
@@ -17,11 +18,13 @@ type ReservationStatus string
 var ReservationStatuses = struct {
 	UnderConsideration ReservationStatus
 	InProgress         ReservationStatus
+	UnderReview        ReservationStatus
 	Done               ReservationStatus
 	Poll               ReservationStatus
 }{
 	UnderConsideration: "Under Consideration",
 	InProgress:         "In Progress",
+	UnderReview:        "Under Review",
 	Done:               "Done",
 	Poll:               "Poll",
 }
@@ -39,6 +42,7 @@ func (s *ReservationStatus) Scan(value interface{}) error {
 		if v, ok := str.(string); ok {
 			if v != string(ReservationStatuses.UnderConsideration) &&
 				v != string(ReservationStatuses.InProgress) &&
+				v != string(ReservationStatuses.UnderReview) &&
 				v != string(ReservationStatuses.Done) &&
 				v != string(ReservationStatuses.Poll) {
 				return errors.New("value is not valid ReservationStatus value")
@@ -88,89 +92,148 @@ func (s *Urls) Scan(value interface{}) error {
 }
 
 type Reservation struct {
-	VkID         int               `db:"vk_id"`
-	Introduction int               `db:"introduction"` // id of vk message contained information
-	Deadline     sql.NullTime      `db:"deadline"`
-	Status       ReservationStatus `db:"status"`
-	Greeting     Urls              `db:"greeting"`
-	Poll         sql.NullInt32     `db:"poll"`
+	VkID         int `db:"vk_id"`
+	Introduction int `db:"introduction"` // id of vk message contained information
+	// dialog with introduction, dialog with user if null
+	IntroductionPeer sql.NullInt32     `db:"introduction_peer"`
+	Deadline         sql.NullTime      `db:"deadline"`
+	Status           ReservationStatus `db:"status"`
+	Greeting         Urls              `db:"greeting"`
+	Poll             sql.NullInt32     `db:"poll"`
 
 	Role
 }
 
-func (a *Ask) AddReservation(vk_id int, role string, introduction int) error {
-	query := sqlf.InsertInto("reservations").
-		Set("vk_id", vk_id).
-		Set("role", role).
-		Set("introduction", introduction)
-
-	if a.config.NoConfirmReservation {
-		query.Set("is_confirmed", 1)
+// peer to forward introduction from
+func (r *Reservation) IntroductionPeerID() int {
+	if r.IntroductionPeer.Valid {
+		return int(r.IntroductionPeer.Int32)
 	}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
-	if err != nil {
-		return zaperr.Wrap(err, "failed to add reservation",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+	return r.VkID
+}
+
+// zero config value is treated as one reservation
+func (a *Ask) MaxReservations() int {
+	if a.config.MaxReservations < 1 {
+		return 1
 	}
 
-	return nil
+	return a.config.MaxReservations
 }
 
-func (a *Ask) ReservationByVkID(vk_id int) (*Reservation, error) {
-	var reservations []Reservation
+// user can make new reservation if the limit of config is not reached
+func (a *Ask) CanReserve(ctx context.Context, vk_id int) (bool, error) {
+	reservations, err := a.storage.ReservationsByVkID(ctx, vk_id)
+	if err != nil {
+		return false, err
+	}
 
-	query := sqlf.From("reservations_details").
-		Bind(&Reservation{}).
-		Where("vk_id = ?", vk_id).
-		Limit(1)
+	return len(reservations) < a.MaxReservations(), nil
+}
 
-	err := a.db.Select(&reservations, query.String(), query.Args()...)
+func (a *Ask) checkReservationsLimit(ctx context.Context, vk_id int) error {
+	ok, err := a.CanReserve(ctx, vk_id)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get reservations by vk id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return err
 	}
 
-	if len(reservations) == 0 {
-		return nil, nil
+	if !ok {
+		err := errors.New("reservations limit is reached")
+		return zaperr.Wrap(err, "",
+			zap.Int("vk_id", vk_id),
+			zap.Int("limit", a.MaxReservations()))
 	}
 
-	// correct time
-	reservations[0].Deadline.Time = reservations[0].Deadline.Time.Add(-a.timezone)
-	return &reservations[0], nil
+	return nil
+}
+
+// banned user can not reserve
+func (a *Ask) AddReservation(ctx context.Context, actor Actor, vk_id int, role string, introduction int) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.checkBan(ctx, vk_id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.checkReservationsLimit(ctx, vk_id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.AddReservation(ctx, vk_id, role, introduction, tx.config.NoConfirmReservation)
+		if err != nil {
+			return err
+		}
+
+		// offered role is claimed
+		err = tx.storage.LeaveWaitlist(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.auditReservation(ctx, actor, AuditActions.AddReservation, vk_id, role, nil)
+	})
 }
 
-func (a *Ask) UnderConsiderationReservations() ([]Reservation, error) {
-	var reservations []Reservation
+// Reservation on behalf of user is made by admin and is confirmed at once.
+// Introduction is optional (0 if absent) and is kept in dialog with admin.
+func (a *Ask) AddConfirmedReservation(ctx context.Context, actor Actor, vk_id int, role string, introduction int) (time.Time, error) {
+	deadline := a.CalculateReservationDeadline()
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.checkReservationsLimit(ctx, vk_id)
+		if err != nil {
+			return err
+		}
+
+		available, err := tx.storage.AvailableRoles(ctx)
+		if err != nil {
+			return err
+		}
 
-	query := sqlf.From("reservations_details").
-		Bind(&Reservation{}).
-		Where("status = ?", ReservationStatuses.UnderConsideration)
+		if !slices.ContainsFunc(available, func(r Role) bool { return r.Name == role }) {
+			err := errors.New("role is not available")
+			return zaperr.Wrap(err, "",
+				zap.Int("vk_id", vk_id),
+				zap.String("role", role))
+		}
+
+		err = tx.storage.AddConfirmedReservation(ctx, vk_id, role, introduction, actor.VkID, deadline)
+		if err != nil {
+			return err
+		}
 
-	err := a.db.Select(&reservations, query.String(), query.Args()...)
+		// waited role is claimed
+		err = tx.storage.LeaveWaitlist(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.auditReservation(ctx, actor, AuditActions.AddReservation, vk_id, role, nil)
+	})
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get reservations under consideration",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return time.Time{}, err
 	}
 
-	return reservations, nil
+	return deadline, nil
 }
 
-func (a *Ask) InProgressReservations() ([]Reservation, error) {
-	var reservations []Reservation
+func (a *Ask) Reservation(ctx context.Context, vk_id int, role string) (*Reservation, error) {
+	reservation, err := a.storage.Reservation(ctx, vk_id, role)
+	if err != nil || reservation == nil {
+		return nil, err
+	}
 
-	query := sqlf.From("reservations_details").
-		Bind(&Reservation{}).
-		Where("status = ?", ReservationStatuses.InProgress)
+	// correct time
+	reservation.Deadline.Time = reservation.Deadline.Time.Add(-a.timezone)
+	return reservation, nil
+}
 
-	err := a.db.Select(&reservations, query.String(), query.Args()...)
+func (a *Ask) ReservationsByVkID(ctx context.Context, vk_id int) ([]Reservation, error) {
+	reservations, err := a.storage.ReservationsByVkID(ctx, vk_id)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get reservations in progress",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
 	for i := range reservations {
@@ -180,17 +243,27 @@ func (a *Ask) InProgressReservations() ([]Reservation, error) {
 	return reservations, nil
 }
 
-func (a *Ask) Reservations() ([]Reservation, error) {
-	var reservations []Reservation
+func (a *Ask) UnderConsiderationReservations(ctx context.Context) ([]Reservation, error) {
+	return a.storage.ReservationsByStatus(ctx, ReservationStatuses.UnderConsideration)
+}
+
+func (a *Ask) InProgressReservations(ctx context.Context) ([]Reservation, error) {
+	reservations, err := a.storage.ReservationsByStatus(ctx, ReservationStatuses.InProgress)
+	if err != nil {
+		return nil, err
+	}
 
-	query := sqlf.From("reservations_details").
-		Bind(&Reservation{})
+	for i := range reservations {
+		reservations[i].Deadline.Time = reservations[i].Deadline.Time.Add(-a.timezone)
+	}
 
-	err := a.db.Select(&reservations, query.String(), query.Args()...)
+	return reservations, nil
+}
+
+func (a *Ask) Reservations(ctx context.Context) ([]Reservation, error) {
+	reservations, err := a.storage.Reservations(ctx)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get reservations details",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
 	for i := range reservations {
@@ -217,135 +290,322 @@ func (a *Ask) CalculateReservationDeadline() time.Time {
 		Add(a.config.ReservationDuration)
 }
 
-// func (a *Ask) ChangeReservationDeadline(vk_id int, deadline time.Time) error {
-// 	query := sqlf.Update("reservations").
-// 		Set("deadline", deadline).
-// 		Where("vk_id = ?", vk_id)
+func (a *Ask) ConfirmReservation(ctx context.Context, actor Actor, vk_id int, role string) (time.Time, error) {
+	deadline := a.CalculateReservationDeadline()
 
-// 	_, err := a.db.Exec(query.String(), query.Args()...)
-// 	if err != nil {
-// 		return zaperr.Wrap(err, "failed to change reservation deadline",
-// 			zap.String("query", query.String()),
-// 			zap.Any("args", query.Args()))
-// 	}
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.storage.Reservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
 
-// 	return nil
-// }
+		err = tx.storage.ConfirmReservation(ctx, vk_id, role, deadline)
+		if err != nil {
+			return err
+		}
 
-// func (a *Ask) ChangeReservationDeadlineByRole(role string, deadline time.Time) error {
-// 	query := sqlf.Update("reservations").
-// 		Set("deadline", deadline).
-// 		Where("role = ?", role)
+		return tx.auditReservation(ctx, actor, AuditActions.ConfirmReservation, vk_id, role, before)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
 
-// 	_, err := a.db.Exec(query.String(), query.Args()...)
-// 	if err != nil {
-// 		return zaperr.Wrap(err, "failed to change reservation deadline",
-// 			zap.String("query", query.String()),
-// 			zap.Any("args", query.Args()))
-// 	}
+	return deadline, nil
+}
 
-// 	return nil
-// }
+type ReservationReminder struct {
+	Reservation
+	// how long before deadline
+	Ahead time.Duration
+}
 
-func (a *Ask) ConfirmReservation(vk_id int) (time.Time, error) {
-	deadline := a.CalculateReservationDeadline()
+// Every reservation in progress gets only the closest to deadline reminder
+// which is due at now. Due reminders are saved, so they are sent once.
+func (a *Ask) DueReservationReminders(ctx context.Context, now time.Time) ([]ReservationReminder, error) {
+	if len(a.config.ReservationReminders) == 0 {
+		return nil, nil
+	}
+
+	aheads := slices.Clone(a.config.ReservationReminders)
+	slices.Sort(aheads)
+
+	var due []ReservationReminder
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		reservations, err := tx.storage.ReservationsByStatus(ctx, ReservationStatuses.InProgress)
+		if err != nil {
+			return err
+		}
+
+		for i := range reservations {
+			if !reservations[i].Deadline.Valid {
+				continue
+			}
 
-	confirm_query := sqlf.Update("reservations").
-		Set("is_confirmed", 1).
-		Where("vk_id = ?", vk_id)
+			// correct time
+			deadline := reservations[i].Deadline.Time.Add(-a.timezone)
+			if !now.Before(deadline) {
+				continue
+			}
 
-	deadline_query := sqlf.With("updated_role",
-		sqlf.From("reservations").
-			Select("role").
-			Where("vk_id = ?", vk_id)).
-		Update("reservations").
-		Set("deadline", deadline).
-		Where("role IN updated_role")
+			index := slices.IndexFunc(aheads, func(ahead time.Duration) bool {
+				return !now.Before(deadline.Add(-ahead))
+			})
+			if index == -1 {
+				continue
+			}
+
+			added, err := tx.storage.AddReservationReminder(ctx,
+				reservations[i].VkID,
+				reservations[i].Name,
+				reservations[i].Deadline.Time,
+				aheads[index])
+			if err != nil {
+				return err
+			}
+
+			if added {
+				due = append(due, ReservationReminder{
+					Reservation: reservations[i],
+					Ahead:       aheads[index],
+				})
+			}
+		}
 
-	tx, err := a.db.NewTransaction()
+		return nil
+	})
 	if err != nil {
-		return time.Time{}, zaperr.Wrap(err, "failed to begin new transaction",
-			zap.String("reason", "confirm reservation"))
+		return nil, err
 	}
 
-	_, err = tx.Exec(confirm_query.String(), confirm_query.Args()...)
-	if err != nil {
-		tx.Rollback()
-		return time.Time{}, zaperr.Wrap(err, "failed to confirm reservation",
-			zap.String("query", confirm_query.String()),
-			zap.Any("args", confirm_query.Args()))
+	for i := range due {
+		due[i].Deadline.Time = due[i].Deadline.Time.Add(-a.timezone)
 	}
 
-	_, err = tx.Exec(deadline_query.String(), deadline_query.Args()...)
+	return due, nil
+}
+
+// declined reservation is moved to archive
+func (a *Ask) DeclineReservation(ctx context.Context, actor Actor, vk_id int, role string, reason string) ([]WaitlistEntry, error) {
+	return a.deleteReservationAndOffer(ctx, actor, AuditActions.DeclineReservation, vk_id, role, ReservationOutcomes.Declined, reason)
+}
+
+// greeting images are saved before their vk links expire,
+// failed downloads are retried by watcher
+func (a *Ask) CompleteReservation(ctx context.Context, actor Actor, vk_id int, role string, greeting Urls) error {
+	err := a.SaveImages(ctx, greeting)
 	if err != nil {
-		tx.Rollback()
-		return time.Time{}, zaperr.Wrap(err, "failed to update reservations' deadline",
-			zap.String("query", deadline_query.String()),
-			zap.Any("args", deadline_query.Args()))
+		return err
 	}
 
-	err = tx.Commit()
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.storage.Reservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		// greeting waits for admin if review is on
+		err = tx.storage.CompleteReservation(ctx, vk_id, role, greeting, !tx.config.ReviewGreetings)
+		if err != nil {
+			return err
+		}
+
+		return tx.auditReservation(ctx, actor, AuditActions.CompleteReservation, vk_id, role, before)
+	})
+}
+
+func (a *Ask) UnderReviewReservations(ctx context.Context) ([]Reservation, error) {
+	reservations, err := a.storage.ReservationsByStatus(ctx, ReservationStatuses.UnderReview)
 	if err != nil {
-		return time.Time{}, zaperr.Wrap(err, "failed to commit transaction",
-			zap.String("reason", "confirm reservation"))
+		return nil, err
 	}
 
-	return deadline, nil
+	for i := range reservations {
+		reservations[i].Deadline.Time = reservations[i].Deadline.Time.Add(-a.timezone)
+	}
+
+	return reservations, nil
 }
 
-func (a *Ask) CompleteReservation(vk_id int, greeting Urls) error {
-	query := sqlf.Update("reservations").
-		Set("greeting", greeting).
-		Where("vk_id = ?", vk_id)
+// accepted greeting goes to poll
+func (a *Ask) AcceptGreeting(ctx context.Context, actor Actor, vk_id int, role string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.underReviewReservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.AcceptGreeting(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.auditReservation(ctx, actor, AuditActions.AcceptGreeting, vk_id, role, before)
+	})
+}
+
+// rejected reservation is in progress again with the same deadline
+func (a *Ask) RejectGreeting(ctx context.Context, actor Actor, vk_id int, role string, comment string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.underReviewReservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.RejectGreeting(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor,
+			AuditActions.RejectGreeting,
+			vk_id,
+			role,
+			before,
+			map[string]interface{}{"comment": comment})
+	})
+}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
+func (a *Ask) underReviewReservation(ctx context.Context, vk_id int, role string) (*Reservation, error) {
+	reservation, err := a.storage.Reservation(ctx, vk_id, role)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to complete reservation",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
-	return nil
+	if reservation == nil || reservation.Status != ReservationStatuses.UnderReview {
+		err := errors.New("reservation is not under review")
+		return nil, zaperr.Wrap(err, "",
+			zap.Int("vk_id", vk_id),
+			zap.String("role", role),
+			zap.Any("reservation", reservation))
+	}
+
+	return reservation, nil
+}
+
+// reservation is moved to archive with outcome
+func (a *Ask) DeleteReservation(ctx context.Context, actor Actor, vk_id int, role string, outcome ReservationOutcome, reason string) ([]WaitlistEntry, error) {
+	return a.deleteReservationAndOffer(ctx, actor, AuditActions.DeleteReservation, vk_id, role, outcome, reason)
 }
 
-func (a *Ask) DeleteReservation(vk_id int) error {
-	query := sqlf.DeleteFrom("reservations").
-		Where("vk_id = ?", vk_id)
+// expired reservations are moved to archive, freed roles are offered to waitlist
+func (a *Ask) DeleteReservationByDeadline(ctx context.Context, actor Actor, deadline time.Time) ([]WaitlistEntry, error) {
+	var offered []WaitlistEntry
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		reservations, err := tx.storage.Reservations(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DeleteReservationByDeadline(ctx, deadline, "deadline is over")
+		if err != nil {
+			return err
+		}
+
+		// the same condition as storage has
+		for i := range reservations {
+			if !reservations[i].Deadline.Valid || !deadline.After(reservations[i].Deadline.Time) ||
+				reservations[i].Status == ReservationStatuses.UnderReview {
+				continue
+			}
+
+			err := tx.audit(ctx, actor,
+				AuditActions.DeleteReservation,
+				reservations[i].VkID,
+				reservations[i].Name,
+				&reservations[i],
+				nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		offered, err = tx.OfferWaitlistRoles(ctx, actor)
+		return err
+	})
 	if err != nil {
-		return zaperr.Wrap(err, "failed to delete reservation by id",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
-	return nil
+	return offered, nil
 }
 
-func (a *Ask) DeleteReservationByDeadline(deadline time.Time) error {
-	query := sqlf.DeleteFrom("reservations").
-		Where("unixepoch(?) - unixepoch(deadline) > 0", deadline)
+func (a *Ask) DeleteReservationByRole(ctx context.Context, actor Actor, role string, outcome ReservationOutcome, reason string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		reservations, err := tx.storage.Reservations(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DeleteReservationByRole(ctx, role, outcome, reason)
+		if err != nil {
+			return err
+		}
 
-	_, err := a.db.Exec(query.String(), query.Args()...)
+		for i := range reservations {
+			if reservations[i].Name != role {
+				continue
+			}
+
+			err := tx.audit(ctx, actor,
+				AuditActions.DeleteReservation,
+				reservations[i].VkID,
+				role,
+				&reservations[i],
+				nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (a *Ask) deleteReservation(ctx context.Context, actor Actor, action AuditAction, vk_id int, role string, outcome ReservationOutcome, reason string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		before, err := tx.storage.Reservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DeleteReservation(ctx, vk_id, role, outcome, reason)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, action, vk_id, role, before, nil)
+	})
+}
+
+// role freed by reservation is offered to waitlist in the same transaction,
+// so it is not shown as available until the first in line gets the offer
+func (a *Ask) deleteReservationAndOffer(ctx context.Context, actor Actor, action AuditAction, vk_id int, role string, outcome ReservationOutcome, reason string) ([]WaitlistEntry, error) {
+	var offered []WaitlistEntry
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.deleteReservation(ctx, actor, action, vk_id, role, outcome, reason)
+		if err != nil {
+			return err
+		}
+
+		offered, err = tx.OfferWaitlistRoles(ctx, actor)
+		return err
+	})
 	if err != nil {
-		return zaperr.Wrap(err, "failed to delete reservation by deadline",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
-	return nil
+	return offered, nil
 }
 
-func (a *Ask) DeleteReservationByRole(role string) error {
-	query := sqlf.DeleteFrom("reservations").
-		Where("role = ?", role)
-
-	_, err := a.db.Exec(query.String(), query.Args()...)
+// reservation after change is taken from storage
+func (a *Ask) auditReservation(ctx context.Context, actor Actor, action AuditAction, vk_id int, role string, before *Reservation) error {
+	after, err := a.storage.Reservation(ctx, vk_id, role)
 	if err != nil {
-		return zaperr.Wrap(err, "failed to delete reservation by role",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return err
 	}
 
-	return nil
+	return a.audit(ctx, actor, action, vk_id, role, before, after)
 }
@@ -0,0 +1,86 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+)
+
+// how reservation is finished
+type ReservationOutcome string
+
+var ReservationOutcomes = struct {
+	Expired   ReservationOutcome
+	Cancelled ReservationOutcome
+	Declined  ReservationOutcome
+	Accepted  ReservationOutcome
+	Deleted   ReservationOutcome
+	// another participant won poll
+	Lost ReservationOutcome
+}{
+	Expired:   "Expired",
+	Cancelled: "Cancelled",
+	Declined:  "Declined",
+	Accepted:  "Accepted",
+	Deleted:   "Deleted",
+	Lost:      "Lost",
+}
+
+func (o ReservationOutcome) Value() (driver.Value, error) {
+	return string(o), nil
+}
+
+func (o *ReservationOutcome) Scan(value interface{}) error {
+	if value == nil {
+		return errors.New("ReservationOutcome is not nullable")
+	}
+	if str, err := driver.String.ConvertValue(value); err == nil {
+		if v, ok := str.(string); ok {
+			// check if is valid
+			if v != string(ReservationOutcomes.Expired) &&
+				v != string(ReservationOutcomes.Cancelled) &&
+				v != string(ReservationOutcomes.Declined) &&
+				v != string(ReservationOutcomes.Accepted) &&
+				v != string(ReservationOutcomes.Deleted) &&
+				v != string(ReservationOutcomes.Lost) {
+				return errors.New("value is not valid ReservationOutcome value")
+			}
+			*o = ReservationOutcome(v)
+			return nil
+		}
+	}
+	return errors.New("failed to scan ReservationOutcome")
+}
+
+type ArchivedReservation struct {
+	Id               int                `db:"id"`
+	VkID             int                `db:"vk_id"`
+	Role             string             `db:"role"`
+	Introduction     int                `db:"introduction"`
+	IntroductionPeer sql.NullInt32      `db:"introduction_peer"`
+	IsConfirmed      bool               `db:"is_confirmed"`
+	Deadline         sql.NullTime       `db:"deadline"`
+	Greeting         Urls               `db:"greeting"`
+	Created          time.Time          `db:"created"`
+	Outcome          ReservationOutcome `db:"outcome"`
+	Reason           string             `db:"reason"`
+	Timestamp        time.Time          `db:"timestamp"`
+}
+
+// the latest first
+func (a *Ask) ArchivedReservations(ctx context.Context, vk_id int) ([]ArchivedReservation, error) {
+	reservations, err := a.storage.ArchivedReservations(ctx, vk_id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		reservations[i].Deadline.Time = reservations[i].Deadline.Time.Add(-a.timezone)
+		reservations[i].Created = reservations[i].Created.Add(a.timezone)
+		reservations[i].Timestamp = reservations[i].Timestamp.Add(a.timezone)
+	}
+
+	return reservations, nil
+}
@@ -0,0 +1,247 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+type ExtensionStatus string
+
+var ExtensionStatuses = struct {
+	Pending  ExtensionStatus
+	Approved ExtensionStatus
+	Declined ExtensionStatus
+}{
+	Pending:  "Pending",
+	Approved: "Approved",
+	Declined: "Declined",
+}
+
+func (s ExtensionStatus) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+func (s *ExtensionStatus) Scan(value interface{}) error {
+	if value == nil {
+		return errors.New("ExtensionStatus is not nullable")
+	}
+	if str, err := driver.String.ConvertValue(value); err == nil {
+		if v, ok := str.(string); ok {
+			// check if is valid
+			if v != string(ExtensionStatuses.Pending) &&
+				v != string(ExtensionStatuses.Approved) &&
+				v != string(ExtensionStatuses.Declined) {
+				return errors.New("value is not valid ExtensionStatus value")
+			}
+			*s = ExtensionStatus(v)
+			return nil
+		}
+	}
+	return errors.New("failed to scan ExtensionStatus")
+}
+
+type ReservationExtension struct {
+	Id        int             `db:"id"`
+	VkID      int             `db:"vk_id"`
+	Role      string          `db:"role"`
+	Days      int             `db:"days"`
+	Reason    string          `db:"reason"`
+	Status    ExtensionStatus `db:"status"`
+	Admin     sql.NullInt32   `db:"admin"`
+	Timestamp time.Time       `db:"timestamp"`
+	Decided   sql.NullTime    `db:"decided"`
+}
+
+// what is left for current deadline of role, it is shared by all reservations
+// of the role, so extensions of every holder are counted
+type ExtensionAllowance struct {
+	Extensions int
+	Days       int
+	// only one request is considered at time
+	Pending bool
+}
+
+func (e ExtensionAllowance) Allowed() bool {
+	return e.Extensions > 0 && e.Days > 0 && !e.Pending
+}
+
+func (a *Ask) ReservationExtensionAllowance(ctx context.Context, role string) (ExtensionAllowance, error) {
+	extensions, err := a.storage.ReservationExtensionsByRole(ctx, role)
+	if err != nil {
+		return ExtensionAllowance{}, err
+	}
+
+	allowance := ExtensionAllowance{
+		Extensions: a.config.MaxReservationExtensions,
+		Days:       a.config.MaxReservationExtensionDays,
+	}
+
+	for _, extension := range extensions {
+		switch extension.Status {
+		case ExtensionStatuses.Pending:
+			allowance.Pending = true
+		case ExtensionStatuses.Approved:
+			allowance.Extensions -= 1
+			allowance.Days -= extension.Days
+		}
+	}
+
+	return allowance, nil
+}
+
+func (a *Ask) PendingReservationExtensions(ctx context.Context) ([]ReservationExtension, error) {
+	extensions, err := a.storage.ReservationExtensionsByStatus(ctx, ExtensionStatuses.Pending)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range extensions {
+		extensions[i].Timestamp = extensions[i].Timestamp.Add(a.timezone)
+	}
+
+	return extensions, nil
+}
+
+// only reservation in progress can be extended within limits of config
+func (a *Ask) RequestReservationExtension(ctx context.Context, actor Actor, vk_id int, role string, days int, reason string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		reservation, err := tx.storage.Reservation(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+		if reservation == nil || reservation.Status != ReservationStatuses.InProgress {
+			err := errors.New("reservation is not in progress")
+			return zaperr.Wrap(err, "",
+				zap.Int("vk_id", vk_id),
+				zap.String("role", role),
+				zap.Any("reservation", reservation))
+		}
+
+		allowance, err := tx.ReservationExtensionAllowance(ctx, role)
+		if err != nil {
+			return err
+		}
+		if !allowance.Allowed() || days <= 0 || days > allowance.Days {
+			err := errors.New("extension is not allowed")
+			return zaperr.Wrap(err, "",
+				zap.Int("vk_id", vk_id),
+				zap.String("role", role),
+				zap.Int("days", days),
+				zap.Any("allowance", allowance))
+		}
+
+		err = tx.storage.AddReservationExtension(ctx, vk_id, role, days, reason)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor,
+			AuditActions.RequestExtension,
+			vk_id,
+			role,
+			nil,
+			map[string]interface{}{"days": days, "reason": reason})
+	})
+}
+
+// deadline of reservation is moved by requested days, new deadline is returned.
+// All reservations of the role share deadline, so it is moved for everyone,
+// holders of the role are returned to be notified.
+func (a *Ask) ApproveReservationExtension(ctx context.Context, actor Actor, id int) (time.Time, []int, error) {
+	var deadline time.Time
+	var holders []int
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		extension, err := tx.pendingExtension(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		before, err := tx.storage.Reservation(ctx, extension.VkID, extension.Role)
+		if err != nil {
+			return err
+		}
+		if before == nil || !before.Deadline.Valid {
+			err := errors.New("reservation has no deadline")
+			return zaperr.Wrap(err, "",
+				zap.Any("extension", extension),
+				zap.Any("reservation", before))
+		}
+
+		reservations, err := tx.storage.ReservationsByRole(ctx, extension.Role)
+		if err != nil {
+			return err
+		}
+
+		deadline = before.Deadline.Time.Add(time.Duration(extension.Days) * 24 * time.Hour)
+
+		err = tx.storage.ChangeReservationDeadlineByRole(ctx, extension.Role, deadline)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DecideReservationExtension(ctx, id, ExtensionStatuses.Approved, actor.VkID)
+		if err != nil {
+			return err
+		}
+
+		for i := range reservations {
+			holders = append(holders, reservations[i].VkID)
+
+			err = tx.auditReservation(ctx, actor, AuditActions.ApproveExtension, reservations[i].VkID, extension.Role, &reservations[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return deadline, holders, nil
+}
+
+func (a *Ask) DeclineReservationExtension(ctx context.Context, actor Actor, id int) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		extension, err := tx.pendingExtension(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.storage.DecideReservationExtension(ctx, id, ExtensionStatuses.Declined, actor.VkID)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor,
+			AuditActions.DeclineExtension,
+			extension.VkID,
+			extension.Role,
+			extension,
+			nil)
+	})
+}
+
+func (a *Ask) pendingExtension(ctx context.Context, id int) (*ReservationExtension, error) {
+	extension, err := a.storage.ReservationExtension(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if extension == nil || extension.Status != ExtensionStatuses.Pending {
+		err := errors.New("extension is not pending")
+		return nil, zaperr.Wrap(err, "",
+			zap.Int("id", id),
+			zap.Any("extension", extension))
+	}
+
+	return extension, nil
+}
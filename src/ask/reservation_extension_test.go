@@ -0,0 +1,147 @@
+package ask
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReservationExtensionLimits(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration:         24 * time.Hour,
+		NoConfirmReservation:        true,
+		MaxReservationExtensions:    2,
+		MaxReservationExtensionDays: 5,
+	}, sqliteStorage(t, fixture))
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.ConfirmReservation(ctx, AdminActor(1), 10, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the other reservation of the role shares deadline
+	err = a.AddReservation(ctx, UserActor(20), 20, "alice", 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline, err := a.ConfirmReservation(ctx, AdminActor(1), 20, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 6, "too long")
+	if err == nil {
+		t.Error("extension over days limit should fail")
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 3, "exams")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 1, "one more")
+	if err == nil {
+		t.Error("only one pending extension is allowed")
+	}
+
+	pending, err := a.PendingReservationExtensions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("wrong pending extensions: %v", pending)
+	}
+
+	extended, holders, err := a.ApproveReservationExtension(ctx, AdminActor(1), pending[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !extended.Equal(deadline.Add(3 * 24 * time.Hour)) {
+		t.Errorf("wrong extended deadline: %v, was %v", extended, deadline)
+	}
+	if !slices.Equal(holders, []int{10, 20}) {
+		t.Errorf("wrong holders to notify: %v", holders)
+	}
+
+	for _, vk_id := range []int{10, 20} {
+		reservation, err := a.Reservation(ctx, vk_id, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reservation.Deadline.Time.Equal(extended) {
+			t.Errorf("deadline of role is not moved for %d: %v", vk_id, reservation.Deadline)
+		}
+	}
+
+	_, _, err = a.ApproveReservationExtension(ctx, AdminActor(1), pending[0].Id)
+	if err == nil {
+		t.Error("extension should not be approved twice")
+	}
+
+	// limits are shared by holders of the role
+	allowance, err := a.ReservationExtensionAllowance(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance != (ExtensionAllowance{Extensions: 1, Days: 2}) {
+		t.Errorf("wrong allowance: %v", allowance)
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(20), 20, "alice", 3, "more than left")
+	if err == nil {
+		t.Error("extension over left days of role should fail")
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(20), 20, "alice", 2, "exams too")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 1, "while other is pending")
+	if err == nil {
+		t.Error("only one pending extension of role is allowed")
+	}
+
+	pending, err = a.PendingReservationExtensions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].VkID != 20 {
+		t.Fatalf("wrong pending extensions: %v", pending)
+	}
+
+	_, _, err = a.ApproveReservationExtension(ctx, AdminActor(1), pending[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vk_id := range []int{10, 20} {
+		reservation, err := a.Reservation(ctx, vk_id, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reservation.Deadline.Time.Equal(deadline.Add(5 * 24 * time.Hour)) {
+			t.Errorf("deadline of role is moved over limit for %d: %v", vk_id, reservation.Deadline)
+		}
+	}
+
+	allowance, err = a.ReservationExtensionAllowance(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowance.Allowed() {
+		t.Errorf("allowance of role should be used up: %v", allowance)
+	}
+
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 1, "one more")
+	if err == nil {
+		t.Error("extension over limits of role should fail")
+	}
+}
@@ -0,0 +1,310 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDueReservationReminders(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration:  24 * time.Hour,
+		ReservationReminders: []time.Duration{6 * time.Hour, 48 * time.Hour},
+	}, sqliteStorage(t, fixture))
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline, err := a.ConfirmReservation(ctx, AdminActor(1), 10, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, step := range []struct {
+		before time.Duration
+		ahead  time.Duration
+	}{
+		{50 * time.Hour, 0},
+		{47 * time.Hour, 48 * time.Hour},
+		// already sent
+		{46 * time.Hour, 0},
+		{5 * time.Hour, 6 * time.Hour},
+		{time.Hour, 0},
+		// deadline is over
+		{-time.Hour, 0},
+	} {
+		due, err := a.DueReservationReminders(ctx, deadline.Add(-step.before))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if step.ahead == 0 {
+			if len(due) != 0 {
+				t.Errorf("unexpected reminders %s before deadline: %v", step.before, due)
+			}
+			continue
+		}
+
+		if len(due) != 1 || due[0].VkID != 10 || due[0].Ahead != step.ahead {
+			t.Errorf("wrong reminders %s before deadline: %v", step.before, due)
+		}
+	}
+}
+
+func TestReservationRemindersArePurged(t *testing.T) {
+	ctx := context.Background()
+
+	s := sqliteStorage(t, fixture).(*SQLite)
+	a := NewWithStorage(&Config{
+		ReservationDuration:         24 * time.Hour,
+		ReservationReminders:        []time.Duration{6 * time.Hour},
+		MaxReservationExtensions:    1,
+		MaxReservationExtensionDays: 1,
+	}, s)
+
+	reminders := func() int {
+		t.Helper()
+
+		var count int
+		err := s.db.Get(ctx, &count, "SELECT COUNT(*) FROM reservation_reminders")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	remind := func() {
+		t.Helper()
+
+		reservation, err := a.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		due, err := a.DueReservationReminders(ctx, reservation.Deadline.Time.Add(-time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(due) != 1 || reminders() != 1 {
+			t.Fatalf("reminder is not sent: %v", due)
+		}
+	}
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.ConfirmReservation(ctx, AdminActor(1), 10, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remind()
+
+	// reminder of old deadline is removed
+	err = a.RequestReservationExtension(ctx, UserActor(10), 10, "alice", 1, "exams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := a.PendingReservationExtensions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = a.ApproveReservationExtension(ctx, AdminActor(1), pending[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := reminders(); count != 0 {
+		t.Errorf("reminders of old deadline are left: %d", count)
+	}
+	remind()
+
+	// reminders of archived reservation are removed
+	_, err = a.DeleteReservation(ctx, UserActor(10), 10, "alice", ReservationOutcomes.Cancelled, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := reminders(); count != 0 {
+		t.Errorf("reminders of archived reservation are left: %d", count)
+	}
+}
+
+func TestMaxReservations(t *testing.T) {
+	ctx := context.Background()
+
+	for _, limit := range []int{0, 2} {
+		t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+			a := NewWithStorage(&Config{
+				ReservationDuration: 24 * time.Hour,
+				MaxReservations:     limit,
+			}, sqliteStorage(t, fixture))
+
+			err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// zero limit is the same as one
+			err = a.AddReservation(ctx, UserActor(10), 10, "bob", 2000)
+			if (err == nil) != (limit == 2) {
+				t.Fatalf("wrong limit of reservations: %v", err)
+			}
+
+			_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 10, "carol", 0)
+			if err == nil {
+				t.Errorf("admin should not exceed limit of reservations")
+			}
+
+			can, err := a.CanReserve(ctx, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if can {
+				t.Errorf("user should not be able to reserve more")
+			}
+
+			_, err = a.DeleteReservation(ctx, UserActor(10), 10, "alice", ReservationOutcomes.Cancelled, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			can, err = a.CanReserve(ctx, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !can {
+				t.Errorf("cancelled reservation should free place")
+			}
+		})
+	}
+}
+
+func TestAddConfirmedReservation(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration: 24 * time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	deadline, err := a.AddConfirmedReservation(ctx, AdminActor(1), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservation, err := a.Reservation(ctx, 10, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation == nil {
+		t.Fatal("reservation is not created")
+	}
+	if reservation.Status != ReservationStatuses.InProgress {
+		t.Errorf("reservation should be in progress: %s", reservation.Status)
+	}
+	if !reservation.Deadline.Time.Equal(deadline) {
+		t.Errorf("wrong deadline: %s != %s", reservation.Deadline.Time, deadline)
+	}
+	if reservation.IntroductionPeerID() != 1 {
+		t.Errorf("introduction should be forwarded from admin: %d", reservation.IntroductionPeerID())
+	}
+
+	// role is already taken by member
+	_, err = a.storage.AddMember(ctx, 30, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 20, "carol", 0)
+	if err == nil {
+		t.Error("role which is not available should not be reserved")
+	}
+
+	_, err = a.AddConfirmedReservation(ctx, AdminActor(1), 20, "bob", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reservation, err = a.Reservation(ctx, 20, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reservation == nil || reservation.IntroductionPeerID() != 20 {
+		t.Errorf("reservation without introduction has no peer: %v", reservation)
+	}
+}
+
+func TestGreetingReview(t *testing.T) {
+	ctx := context.Background()
+
+	for _, review := range []bool{false, true} {
+		t.Run(fmt.Sprintf("review=%t", review), func(t *testing.T) {
+			a := NewWithStorage(&Config{
+				ReservationDuration: 24 * time.Hour,
+				ReviewGreetings:     review,
+			}, sqliteStorage(t, fixture))
+
+			deadline, err := a.AddConfirmedReservation(ctx, AdminActor(1), 10, "alice", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := func(expected ReservationStatus) {
+				t.Helper()
+
+				reservation, err := a.Reservation(ctx, 10, "alice")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if reservation.Status != expected {
+					t.Errorf("wrong status with review %t: %s != %s", review, reservation.Status, expected)
+				}
+				if !reservation.Deadline.Time.Equal(deadline) {
+					t.Errorf("deadline should be kept: %v", reservation.Deadline)
+				}
+			}
+
+			err = a.CompleteReservation(ctx, UserActor(10), 10, "alice", Urls{"https://example.com/greeting.png"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !review {
+				status(ReservationStatuses.Done)
+
+				err = a.AcceptGreeting(ctx, AdminActor(1), 10, "alice")
+				if err == nil {
+					t.Error("greeting which is not under review should not be accepted")
+				}
+				return
+			}
+
+			status(ReservationStatuses.UnderReview)
+
+			// admins may review greeting after deadline
+			_, err = a.DeleteReservationByDeadline(ctx, WatcherActor, deadline.Add(time.Second))
+			if err != nil {
+				t.Fatal(err)
+			}
+			status(ReservationStatuses.UnderReview)
+
+			err = a.RejectGreeting(ctx, AdminActor(1), 10, "alice", "too dark")
+			if err != nil {
+				t.Fatal(err)
+			}
+			status(ReservationStatuses.InProgress)
+
+			err = a.CompleteReservation(ctx, UserActor(10), 10, "alice", Urls{"https://example.com/greeting.png"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = a.AcceptGreeting(ctx, AdminActor(1), 10, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			status(ReservationStatuses.Done)
+		})
+	}
+}
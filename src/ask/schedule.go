@@ -2,6 +2,7 @@ package ask
 
 import (
 	"ask-bot/src/datatypes/schedule"
+	"context"
 	"database/sql/driver"
 	"errors"
 	"slices"
@@ -9,7 +10,6 @@ import (
 	"time"
 
 	"github.com/hori-ryota/zaperr"
-	"github.com/leporo/sqlf"
 	"go.uber.org/zap"
 )
 
@@ -63,18 +63,10 @@ type Timeslot struct {
 	TimePoints string       `db:"time_points"`
 }
 
-func (a *Ask) Schedule(kind TimeslotKind, begin time.Time, end time.Time) (schedule.Schedule, error) {
-	// get timeslots
-	var timeslots []Timeslot
-	query := sqlf.From("schedule").
-		Bind(&Timeslot{}).
-		Where("kind = ?", kind)
-
-	err := a.db.Select(&timeslots, query.String(), query.Args()...)
+func (a *Ask) Schedule(ctx context.Context, kind TimeslotKind, begin time.Time, end time.Time) (schedule.Schedule, error) {
+	timeslots, err := a.storage.Timeslots(ctx, kind)
 	if err != nil {
-		return nil, zaperr.Wrap(err, "failed to get schedule",
-			zap.String("query", query.String()),
-			zap.Any("args", query.Args()))
+		return nil, err
 	}
 
 	if len(timeslots) == 0 {
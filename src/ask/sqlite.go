@@ -0,0 +1,23 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"context"
+)
+
+// SQLite is storage upon database described by schema.sql.
+type SQLite struct {
+	db db.Queryer
+}
+
+func NewSQLite(d db.Queryer) *SQLite {
+	return &SQLite{
+		db: d,
+	}
+}
+
+func (s *SQLite) Transaction(ctx context.Context, fn func(s Storage) error) error {
+	return s.db.Transaction(ctx, func(tx db.Queryer) error {
+		return fn(NewSQLite(tx))
+	})
+}
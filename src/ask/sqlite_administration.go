@@ -0,0 +1,26 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) IsAdmin(ctx context.Context, vk_id int) (bool, error) {
+	var admin []Administration
+
+	query := sqlf.From("administration").
+		Bind(&Administration{}).
+		Where("vk_id = ?", vk_id)
+
+	err := s.db.Select(ctx, &admin, query.String(), query.Args()...)
+	if err != nil {
+		return false, zaperr.Wrap(err, "failed to get administration",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return len(admin) > 0, nil
+}
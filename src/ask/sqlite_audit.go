@@ -0,0 +1,69 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) AddAuditRecord(ctx context.Context, record AuditRecord) error {
+	query := sqlf.InsertInto("audit_log").
+		Set("actor_kind", record.ActorKind).
+		Set("actor", record.Actor).
+		Set("action", record.Action).
+		Set("vk_id", record.VkID).
+		Set("role", record.Role).
+		Set("before", record.Before).
+		Set("after", record.After)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add audit record",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+// records about user or made by user, the latest first
+func (s *SQLite) AuditByVkID(ctx context.Context, vk_id int, limit int) ([]AuditRecord, error) {
+	var records []AuditRecord
+
+	query := sqlf.From("audit_log").
+		Bind(&AuditRecord{}).
+		Where("vk_id = ? OR actor = ?", vk_id, vk_id).
+		OrderBy("timestamp DESC", "id DESC").
+		Limit(limit)
+
+	err := s.db.Select(ctx, &records, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get audit records by vk id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return records, nil
+}
+
+// the latest first
+func (s *SQLite) AuditByRole(ctx context.Context, role string, limit int) ([]AuditRecord, error) {
+	var records []AuditRecord
+
+	query := sqlf.From("audit_log").
+		Bind(&AuditRecord{}).
+		Where("role = ?", role).
+		OrderBy("timestamp DESC", "id DESC").
+		Limit(limit)
+
+	err := s.db.Select(ctx, &records, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get audit records by role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return records, nil
+}
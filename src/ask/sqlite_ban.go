@@ -0,0 +1,81 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+// new ban replaces previous one of user
+func (s *SQLite) AddBan(ctx context.Context, vk_id int, reason string, until sql.NullTime, admin int) error {
+	query := sqlf.New(`INSERT OR REPLACE INTO bans
+		(vk_id, reason, until, admin) VALUES (?, ?, ?, ?)`,
+		vk_id, reason, until, admin)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add ban",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) Ban(ctx context.Context, vk_id int, now time.Time) (*Ban, error) {
+	var bans []Ban
+
+	query := sqlf.From("bans").
+		Bind(&Ban{}).
+		Where("vk_id = ?", vk_id).
+		Where("(until IS NULL OR unixepoch(until) > unixepoch(?))", now)
+
+	err := s.db.Select(ctx, &bans, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get ban",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(bans) == 0 {
+		return nil, nil
+	}
+
+	return &bans[0], nil
+}
+
+func (s *SQLite) Bans(ctx context.Context, now time.Time) ([]Ban, error) {
+	var bans []Ban
+
+	query := sqlf.From("bans").
+		Bind(&Ban{}).
+		Where("(until IS NULL OR unixepoch(until) > unixepoch(?))", now).
+		OrderBy("unixepoch(timestamp)", "vk_id")
+
+	err := s.db.Select(ctx, &bans, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get bans",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return bans, nil
+}
+
+func (s *SQLite) DeleteBan(ctx context.Context, vk_id int) error {
+	query := sqlf.DeleteFrom("bans").
+		Where("vk_id = ?", vk_id)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to delete ban",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package ask
+
+import (
+	"context"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) Deadline(ctx context.Context, member int) (Deadline, error) {
+	var deadline Deadline
+
+	// should be at least one record
+	query := sqlf.From("deadlines").
+		Bind(&deadline).
+		Where("member = ?", member)
+
+	err := s.db.Get(ctx, &deadline, query.String(), query.Args()...)
+	if err != nil {
+		return Deadline{}, zaperr.Wrap(err, "failed to get deadline for member",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return deadline, nil
+}
+
+func (s *SQLite) DeadlineJournal(ctx context.Context, member int) ([]DeadlineEvent, error) {
+	var history []DeadlineEvent
+
+	query := sqlf.From("deadline_journal").
+		Bind(&DeadlineEvent{}).
+		Where("member = ?", member).
+		OrderBy("timestamp DESC")
+
+	err := s.db.Select(ctx, &history, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get deadline journal for member",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return history, nil
+}
+
+// TO-DO maybe another way to insert
+func (s *SQLite) ChangeDeadline(ctx context.Context, member int, diff time.Duration, kind DeadlineCause, cause string) error {
+	query := sqlf.InsertInto("deadline_journal").
+		Set("member", member).
+		Set("diff", int64(diff.Seconds())).
+		Set("kind", kind).
+		Set("cause", cause)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to insert deadline event",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
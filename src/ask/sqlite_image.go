@@ -0,0 +1,47 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+// image of known url is not changed
+func (s *SQLite) AddImage(ctx context.Context, image Image) error {
+	query := sqlf.New(`INSERT OR IGNORE INTO images
+		(url, hash, content_type, size)
+		VALUES (?, ?, ?, ?)`,
+		image.Url, image.Hash, image.ContentType, image.Size)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add image",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) ImageByUrl(ctx context.Context, url string) (*Image, error) {
+	var images []Image
+
+	query := sqlf.From("images").
+		Bind(&Image{}).
+		Where("url = ?", url)
+
+	err := s.db.Select(ctx, &images, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get image by url",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	return &images[0], nil
+}
@@ -0,0 +1,83 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) Member(ctx context.Context, id int) (Member, error) {
+	var member Member
+
+	query := sqlf.From("members_details").
+		Bind(&Member{}).
+		Where("id = ?", id)
+
+	err := s.db.Get(ctx, &member, query.String(), query.Args()...)
+	if err != nil {
+		return Member{}, zaperr.Wrap(err, "failed to get member by id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return member, nil
+}
+
+func (s *SQLite) MemberByRole(ctx context.Context, role string) (Member, error) {
+	var member Member
+
+	query := sqlf.From("members_details").
+		Bind(&Member{}).
+		Where("name = ?", role)
+
+	err := s.db.Get(ctx, &member, query.String(), query.Args()...)
+	if err != nil {
+		return Member{}, zaperr.Wrap(err, "failed to get member by role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return member, nil
+}
+
+func (s *SQLite) MembersByVkID(ctx context.Context, vk_id int) ([]Member, error) {
+	var members []Member
+
+	query := sqlf.From("members_details").
+		Bind(&Member{}).
+		Where("vk_id = ?", vk_id)
+
+	err := s.db.Select(ctx, &members, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get members by vk_id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return members, nil
+}
+
+// returns id of new member
+func (s *SQLite) AddMember(ctx context.Context, vk_id int, role string) (int, error) {
+	query := sqlf.InsertInto("members").
+		Set("vk_id", vk_id).
+		Set("role", role)
+
+	result, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return 0, zaperr.Wrap(err, "failed to add member",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	member, err := result.LastInsertId()
+	if err != nil {
+		return 0, zaperr.Wrap(err, "failed to get last inserted id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return int(member), nil
+}
@@ -0,0 +1,83 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) PendingPolls(ctx context.Context) ([]PendingPoll, error) {
+	var polls []PendingPoll
+
+	query := sqlf.From("pending_polls").
+		Bind(&PendingPoll{}).
+		OrderBy("name")
+
+	err := s.db.Select(ctx, &polls, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get pending polls",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return polls, nil
+}
+
+func (s *SQLite) SavePollAnswers(ctx context.Context, poll_id int, answers []PollAnswer) error {
+	query := sqlf.InsertInto("poll_answer_cache")
+
+	for _, answer := range answers {
+		query.NewRow().
+			Set("poll_id", poll_id).
+			Set("answer_id", answer.ID).
+			Set("value", answer.Value)
+	}
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to save poll answers",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+// false is returned if answer is not cached
+func (s *SQLite) LoadPollAnswer(ctx context.Context, poll_id int, answer_id int) (int, bool, error) {
+	var values []int
+
+	query := sqlf.From("poll_answer_cache").
+		Select("value").
+		Where("poll_id = ?", poll_id).
+		Where("answer_id = ?", answer_id)
+
+	err := s.db.Select(ctx, &values, query.String(), query.Args()...)
+	if err != nil {
+		return 0, false, zaperr.Wrap(err, "failed to load poll answer",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(values) == 0 {
+		return 0, false, nil
+	}
+
+	return values[0], true, nil
+}
+
+func (s *SQLite) DeletePollAnswers(ctx context.Context, poll_id int) error {
+	query := sqlf.DeleteFrom("poll_answer_cache").
+		Where("poll_id = ?", poll_id)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to delete poll answers",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) PointsByVkID(ctx context.Context, vk_id int) (int, error) {
+	var points int
+
+	// zero is default value, it is not a error if it is null
+	query := sqlf.From("points").
+		Select("COALESCE(SUM(diff), 0)").
+		Where("vk_id = ?", vk_id)
+
+	err := s.db.Get(ctx, &points, query.String(), query.Args()...)
+	if err != nil {
+		return -1, zaperr.Wrap(err, "failed to get points by vk id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return points, nil
+}
+
+func (s *SQLite) HistoryPointsByVkID(ctx context.Context, vk_id int) ([]Points, error) {
+	var history []Points
+
+	query := sqlf.From("points").
+		Bind(&Points{}).
+		Where("vk_id = ?", vk_id).
+		OrderBy("timestamp DESC")
+
+	err := s.db.Select(ctx, &history, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get history of points by vk id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return history, nil
+}
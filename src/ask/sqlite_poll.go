@@ -0,0 +1,167 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) OngoingPolls(ctx context.Context) ([]OngoingPoll, error) {
+	var polls []OngoingPoll
+
+	query := sqlf.From("ongoing_polls").
+		Bind(&OngoingPoll{})
+
+	err := s.db.Select(ctx, &polls, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get ongoing polls",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return polls, nil
+}
+
+func (s *SQLite) AddOngoingPoll(ctx context.Context, role string, post int) error {
+	query := sqlf.InsertInto("ongoing_polls").
+		Set("role", role).
+		Set("post", post)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add ongoing poll",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) Polls(ctx context.Context) ([]Poll, error) {
+	var polls []Poll
+
+	query := sqlf.From("polls_details").
+		Bind(&Poll{})
+
+	err := s.db.Select(ctx, &polls, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get polls",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return polls, nil
+}
+
+func (s *SQLite) DeleteOngoingPoll(ctx context.Context, role string) error {
+	query := sqlf.DeleteFrom("ongoing_polls").
+		Where("role = ?", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to delete ongoing poll",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) AddPollDecision(ctx context.Context, decision PollDecision) error {
+	query := sqlf.InsertInto("poll_decisions").
+		Set("role", decision.Role).
+		Set("post", decision.Post).
+		Set("participants", decision.Participants).
+		Set("kind", decision.Kind).
+		Set("winner", decision.Winner).
+		Set("results", decision.Results)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add poll decision",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) LastPollDecision(ctx context.Context, role string) (*PollDecision, error) {
+	var decisions []PollDecision
+
+	query := sqlf.From("poll_decisions").
+		Bind(&PollDecision{}).
+		Where("role = ?", role).
+		OrderBy("id DESC").
+		Limit(1)
+
+	err := s.db.Select(ctx, &decisions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get last poll decision",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(decisions) == 0 {
+		return nil, nil
+	}
+
+	return &decisions[0], nil
+}
+
+func (s *SQLite) PollDecisions(ctx context.Context, limit int) ([]PollDecision, error) {
+	var decisions []PollDecision
+
+	query := sqlf.From("poll_decisions").
+		Bind(&PollDecision{}).
+		OrderBy("id DESC").
+		Limit(limit)
+
+	err := s.db.Select(ctx, &decisions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get poll decisions",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return decisions, nil
+}
+
+func (s *SQLite) PollDecisionsByRole(ctx context.Context, role string, limit int) ([]PollDecision, error) {
+	var decisions []PollDecision
+
+	query := sqlf.From("poll_decisions").
+		Bind(&PollDecision{}).
+		Where("role = ?", role).
+		OrderBy("id DESC").
+		Limit(limit)
+
+	err := s.db.Select(ctx, &decisions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get poll decisions by role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return decisions, nil
+}
+
+func (s *SQLite) PollDecisionsRoles(ctx context.Context, prefix string) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("roles").
+		Bind(&Role{}).
+		Where("shown_name like ?", prefix+"%").
+		Where("name IN (SELECT role FROM poll_decisions)")
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get roles of poll decisions",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
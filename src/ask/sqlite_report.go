@@ -0,0 +1,27 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+// records of every user and role are in chronological order
+func (s *SQLite) ReservationsHistory(ctx context.Context) ([]ReservationHistoryRecord, error) {
+	var records []ReservationHistoryRecord
+
+	query := sqlf.From("reservations_history_details").
+		Bind(&ReservationHistoryRecord{}).
+		OrderBy("vk_id", "role", "id")
+
+	err := s.db.Select(ctx, &records, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservations history",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return records, nil
+}
@@ -0,0 +1,351 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"context"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) AddReservation(ctx context.Context, vk_id int, role string, introduction int, is_confirmed bool) error {
+	query := sqlf.InsertInto("reservations").
+		Set("vk_id", vk_id).
+		Set("role", role).
+		Set("introduction", introduction)
+
+	if is_confirmed {
+		query.Set("is_confirmed", 1)
+	}
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add reservation",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+// reservation made by admin is confirmed at once,
+// introduction is optional and is kept in dialog with admin
+func (s *SQLite) AddConfirmedReservation(ctx context.Context, vk_id int, role string, introduction int, introduction_peer int, deadline time.Time) error {
+	query := sqlf.InsertInto("reservations").
+		Set("vk_id", vk_id).
+		Set("role", role).
+		Set("introduction", introduction).
+		Set("is_confirmed", 1).
+		Set("deadline", deadline)
+
+	if introduction != 0 {
+		query.Set("introduction_peer", introduction_peer)
+	}
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add confirmed reservation",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) Reservation(ctx context.Context, vk_id int, role string) (*Reservation, error) {
+	var reservations []Reservation
+
+	query := sqlf.From("reservations_details").
+		Bind(&Reservation{}).
+		Where("vk_id = ?", vk_id).
+		Where("name = ?", role)
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservation",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(reservations) == 0 {
+		return nil, nil
+	}
+
+	return &reservations[0], nil
+}
+
+// reservations are ordered by time they were made
+func (s *SQLite) ReservationsByVkID(ctx context.Context, vk_id int) ([]Reservation, error) {
+	var reservations []Reservation
+
+	query := sqlf.From("reservations_details").
+		Bind(&Reservation{}).
+		Where("vk_id = ?", vk_id).
+		OrderBy("unixepoch(timestamp)", "name")
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservations by vk id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return reservations, nil
+}
+
+func (s *SQLite) ReservationsByStatus(ctx context.Context, status ReservationStatus) ([]Reservation, error) {
+	var reservations []Reservation
+
+	query := sqlf.From("reservations_details").
+		Bind(&Reservation{}).
+		Where("status = ?", status)
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservations by status",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return reservations, nil
+}
+
+// reservations are ordered by time they were made
+func (s *SQLite) ReservationsByRole(ctx context.Context, role string) ([]Reservation, error) {
+	var reservations []Reservation
+
+	query := sqlf.From("reservations_details").
+		Bind(&Reservation{}).
+		Where("name = ?", role).
+		OrderBy("unixepoch(timestamp)", "vk_id")
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservations by role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return reservations, nil
+}
+
+func (s *SQLite) Reservations(ctx context.Context) ([]Reservation, error) {
+	var reservations []Reservation
+
+	query := sqlf.From("reservations_details").
+		Bind(&Reservation{})
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservations details",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return reservations, nil
+}
+
+// all reservations of the role share deadline
+func (s *SQLite) ChangeReservationDeadlineByRole(ctx context.Context, role string, deadline time.Time) error {
+	query := sqlf.Update("reservations").
+		Set("deadline", deadline).
+		Where("role = ?", role)
+
+	return s.db.Transaction(ctx, func(tx db.Queryer) error {
+		_, err := tx.Exec(ctx, query.String(), query.Args()...)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to change reservations' deadline",
+				zap.String("query", query.String()),
+				zap.Any("args", query.Args()))
+		}
+
+		return deleteStaleReservationReminders(ctx, tx)
+	})
+}
+
+// all reservations of the role share deadline
+func (s *SQLite) ConfirmReservation(ctx context.Context, vk_id int, role string, deadline time.Time) error {
+	confirm_query := sqlf.Update("reservations").
+		Set("is_confirmed", 1).
+		Where("vk_id = ?", vk_id).
+		Where("role = ?", role)
+
+	deadline_query := sqlf.Update("reservations").
+		Set("deadline", deadline).
+		Where("role = ?", role)
+
+	return s.db.Transaction(ctx, func(tx db.Queryer) error {
+		_, err := tx.Exec(ctx, confirm_query.String(), confirm_query.Args()...)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to confirm reservation",
+				zap.String("query", confirm_query.String()),
+				zap.Any("args", confirm_query.Args()))
+		}
+
+		_, err = tx.Exec(ctx, deadline_query.String(), deadline_query.Args()...)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to update reservations' deadline",
+				zap.String("query", deadline_query.String()),
+				zap.Any("args", deadline_query.Args()))
+		}
+
+		return deleteStaleReservationReminders(ctx, tx)
+	})
+}
+
+func (s *SQLite) CompleteReservation(ctx context.Context, vk_id int, role string, greeting Urls, is_accepted bool) error {
+	query := sqlf.Update("reservations").
+		Set("greeting", greeting).
+		Set("is_greeting_accepted", is_accepted).
+		Where("vk_id = ?", vk_id).
+		Where("role = ?", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to complete reservation",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) AcceptGreeting(ctx context.Context, vk_id int, role string) error {
+	query := sqlf.Update("reservations").
+		Set("is_greeting_accepted", 1).
+		Where("vk_id = ?", vk_id).
+		Where("role = ?", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to accept greeting",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+// greeting is removed, deadline is kept
+func (s *SQLite) RejectGreeting(ctx context.Context, vk_id int, role string) error {
+	query := sqlf.Update("reservations").
+		SetExpr("greeting", "NULL").
+		Set("is_greeting_accepted", 0).
+		Where("vk_id = ?", vk_id).
+		Where("role = ?", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to reject greeting",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) DeleteReservation(ctx context.Context, vk_id int, role string, outcome ReservationOutcome, reason string) error {
+	return s.archiveReservations(ctx, outcome, reason, "vk_id = ? AND role = ?", vk_id, role)
+}
+
+// greeting under review is not expired, admins are waited
+func (s *SQLite) DeleteReservationByDeadline(ctx context.Context, deadline time.Time, reason string) error {
+	return s.archiveReservations(ctx, ReservationOutcomes.Expired, reason,
+		"unixepoch(?) - unixepoch(deadline) > 0 AND status != ?", deadline, ReservationStatuses.UnderReview)
+}
+
+func (s *SQLite) DeleteReservationByRole(ctx context.Context, role string, outcome ReservationOutcome, reason string) error {
+	return s.archiveReservations(ctx, outcome, reason, "role = ?", role)
+}
+
+// reservations matched by condition are moved to archive
+func (s *SQLite) archiveReservations(ctx context.Context, outcome ReservationOutcome, reason string, condition string, args ...interface{}) error {
+	archive_query := sqlf.New(`INSERT INTO reservations_archive
+		(vk_id, role, introduction, introduction_peer, is_confirmed, deadline, greeting, created, outcome, reason)
+		SELECT vk_id, role, introduction, introduction_peer, is_confirmed, deadline, greeting, timestamp, ?, ?`,
+		outcome, reason).
+		From("reservations").
+		Where(condition, args...)
+
+	delete_query := sqlf.DeleteFrom("reservations").
+		Where(condition, args...)
+
+	return s.db.Transaction(ctx, func(tx db.Queryer) error {
+		_, err := tx.Exec(ctx, archive_query.String(), archive_query.Args()...)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to archive reservations",
+				zap.String("query", archive_query.String()),
+				zap.Any("args", archive_query.Args()))
+		}
+
+		_, err = tx.Exec(ctx, delete_query.String(), delete_query.Args()...)
+		if err != nil {
+			return zaperr.Wrap(err, "failed to delete reservations",
+				zap.String("query", delete_query.String()),
+				zap.Any("args", delete_query.Args()))
+		}
+
+		return deleteStaleReservationReminders(ctx, tx)
+	})
+}
+
+// reminders of archived reservations and of changed deadlines are not needed
+func deleteStaleReservationReminders(ctx context.Context, tx db.Queryer) error {
+	query := sqlf.DeleteFrom("reservation_reminders").
+		Where(`NOT EXISTS (SELECT 1 FROM reservations
+			WHERE reservations.vk_id = reservation_reminders.vk_id
+			AND reservations.role = reservation_reminders.role
+			AND unixepoch(reservations.deadline) = unixepoch(reservation_reminders.deadline))`)
+
+	_, err := tx.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to delete stale reservation reminders",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) ArchivedReservations(ctx context.Context, vk_id int) ([]ArchivedReservation, error) {
+	var reservations []ArchivedReservation
+
+	query := sqlf.From("reservations_archive").
+		Bind(&ArchivedReservation{}).
+		Where("vk_id = ?", vk_id).
+		OrderBy("timestamp DESC", "id DESC")
+
+	err := s.db.Select(ctx, &reservations, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get archived reservations",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return reservations, nil
+}
+
+// false is returned if reminder is already sent
+func (s *SQLite) AddReservationReminder(ctx context.Context, vk_id int, role string, deadline time.Time, ahead time.Duration) (bool, error) {
+	query := sqlf.New(`INSERT OR IGNORE INTO reservation_reminders
+		(vk_id, role, deadline, ahead) VALUES (?, ?, ?, ?)`,
+		vk_id, role, deadline, int(ahead.Seconds()))
+
+	result, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return false, zaperr.Wrap(err, "failed to add reservation reminder",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, zaperr.Wrap(err, "failed to get affected rows of reservation reminder",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,116 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+// extension belongs to current reservation, not to finished one of the same role
+const currentExtension = `EXISTS (
+	SELECT * FROM reservations
+	WHERE reservations.vk_id = reservation_extensions.vk_id
+		AND reservations.role = reservation_extensions.role
+		AND unixepoch(reservations.timestamp) <= unixepoch(reservation_extensions.timestamp))`
+
+// approved extension moved shared deadline of the role, so it counts
+// while any reservation made before it is not finished
+const currentRoleExtension = `EXISTS (
+	SELECT * FROM reservations
+	WHERE reservations.role = reservation_extensions.role
+		AND unixepoch(reservations.timestamp) <= unixepoch(reservation_extensions.timestamp))`
+
+func (s *SQLite) AddReservationExtension(ctx context.Context, vk_id int, role string, days int, reason string) error {
+	query := sqlf.InsertInto("reservation_extensions").
+		Set("vk_id", vk_id).
+		Set("role", role).
+		Set("days", days).
+		Set("reason", reason)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to add reservation extension",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) ReservationExtension(ctx context.Context, id int) (*ReservationExtension, error) {
+	var extensions []ReservationExtension
+
+	query := sqlf.From("reservation_extensions").
+		Bind(&ReservationExtension{}).
+		Where("id = ?", id)
+
+	err := s.db.Select(ctx, &extensions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservation extension",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+
+	return &extensions[0], nil
+}
+
+func (s *SQLite) ReservationExtensionsByRole(ctx context.Context, role string) ([]ReservationExtension, error) {
+	var extensions []ReservationExtension
+
+	query := sqlf.From("reservation_extensions").
+		Bind(&ReservationExtension{}).
+		Where("("+currentExtension+" OR (status = ? AND "+currentRoleExtension+"))", ExtensionStatuses.Approved).
+		Where("role = ?", role).
+		OrderBy("id")
+
+	err := s.db.Select(ctx, &extensions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservation extensions by role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return extensions, nil
+}
+
+func (s *SQLite) ReservationExtensionsByStatus(ctx context.Context, status ExtensionStatus) ([]ReservationExtension, error) {
+	var extensions []ReservationExtension
+
+	query := sqlf.From("reservation_extensions").
+		Bind(&ReservationExtension{}).
+		Where(currentExtension).
+		Where("status = ?", status).
+		OrderBy("id")
+
+	err := s.db.Select(ctx, &extensions, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get reservation extensions by status",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return extensions, nil
+}
+
+func (s *SQLite) DecideReservationExtension(ctx context.Context, id int, status ExtensionStatus, admin int) error {
+	query := sqlf.Update("reservation_extensions").
+		Set("status", status).
+		Set("admin", admin).
+		SetExpr("decided", "CURRENT_TIMESTAMP").
+		Where("id = ?", id)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to decide reservation extension",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package ask
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) Roles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("roles").
+		Bind(&Role{})
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get roles",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) AvailableRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("available_roles").
+		Bind(&Role{})
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get available roles",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) RolesStartWith(ctx context.Context, prefix string) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("roles").
+		Bind(&Role{}).
+		Where("shown_name like ?", prefix+"%")
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get roles starts with",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) AvailableRolesStartWith(ctx context.Context, prefix string) ([]Role, error) {
+	var roles []Role
+	query := sqlf.From("available_roles").
+		Bind(&Role{}).
+		Where("shown_name like ?", prefix+"%")
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get available roles starts with",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) Role(ctx context.Context, name string) (Role, error) {
+	var role Role
+
+	query := sqlf.From("roles").
+		Bind(&Role{}).
+		Where("name = ?", name)
+
+	err := s.db.Get(ctx, &role, query.String(), query.Args()...)
+	if err != nil {
+		return Role{}, zaperr.Wrap(err, "failed to get role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return role, nil
+}
+
+// roles order by hashtags
+func (s *SQLite) RolesDictionary(ctx context.Context) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("roles").
+		Bind(&Role{}).
+		OrderBy("hashtag")
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get roles dictionary",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) MatchHashtags(ctx context.Context, hashtags []string) ([]MatchedHashtag, error) {
+	var matched []MatchedHashtag
+
+	values := make([]string, len(hashtags))
+	args := make([]interface{}, len(hashtags))
+
+	for i := range hashtags {
+		values[i] = "(?)"
+		args[i] = hashtags[i]
+	}
+
+	subquery := sqlf.New(
+		fmt.Sprintf("VALUES %s", strings.Join(values, ",")),
+		args...,
+	)
+
+	query := sqlf.With("hashtags(value)", subquery).
+		From("hashtags").
+		LeftJoin("roles", "lower(hashtags.value) = lower(roles.hashtag)").
+		Select("hashtags.value as hashtag").
+		Select("roles.name as role").
+		OrderBy("hashtag")
+
+	err := s.db.Select(ctx, &matched, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to match hashtags",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return matched, nil
+}
+
+func (s *SQLite) ChangeAlbums(ctx context.Context, albums map[string]int) error {
+	keys := make([]interface{}, len(albums))
+	values := make([]string, len(albums))
+
+	index := 0
+	for key, value := range albums {
+		keys[index] = key
+		values[index] = fmt.Sprintf("WHEN '%s' THEN %d", key, value)
+
+		index++
+	}
+
+	params := strings.Repeat("?,", len(keys))
+
+	query := sqlf.Update("roles").
+		Clause(fmt.Sprintf("SET album = CASE name %s END", strings.Join(values, " "))).
+		Where(fmt.Sprintf("name IN (%s)", params[:len(params)-1]), keys...)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to change albums",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) ChangeBoards(ctx context.Context, boards map[string]int) error {
+	keys := make([]interface{}, len(boards))
+	values := make([]string, len(boards))
+
+	index := 0
+	for key, value := range boards {
+		keys[index] = key
+		values[index] = fmt.Sprintf("WHEN '%s' THEN %d", key, value)
+
+		index++
+	}
+
+	params := strings.Repeat("?,", len(keys))
+
+	query := sqlf.Update("roles").
+		Clause(fmt.Sprintf("SET board = CASE name %s END", strings.Join(values, " "))).
+		Where(fmt.Sprintf("name IN (%s)", params[:len(params)-1]), keys...)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to change boards",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package ask
+
+import (
+	"context"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) Timeslots(ctx context.Context, kind TimeslotKind) ([]Timeslot, error) {
+	var timeslots []Timeslot
+
+	query := sqlf.From("schedule").
+		Bind(&Timeslot{}).
+		Where("kind = ?", kind)
+
+	err := s.db.Select(ctx, &timeslots, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get schedule",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return timeslots, nil
+}
@@ -0,0 +1,130 @@
+package ask
+
+import (
+	"context"
+	"time"
+
+	"github.com/hori-ryota/zaperr"
+	"github.com/leporo/sqlf"
+	"go.uber.org/zap"
+)
+
+func (s *SQLite) WaitlistRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+
+	query := sqlf.From("waitlist_roles").
+		Bind(&Role{})
+
+	err := s.db.Select(ctx, &roles, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get waitlist roles",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return roles, nil
+}
+
+func (s *SQLite) WaitlistByVkID(ctx context.Context, vk_id int) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+
+	query := sqlf.From("waitlist_details").
+		Bind(&WaitlistEntry{}).
+		Where("vk_id = ?", vk_id).
+		OrderBy("id")
+
+	err := s.db.Select(ctx, &entries, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get waitlist by vk id",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return entries, nil
+}
+
+func (s *SQLite) JoinWaitlist(ctx context.Context, vk_id int, role string) error {
+	query := sqlf.InsertInto("waitlist").
+		Set("vk_id", vk_id).
+		Set("role", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to join waitlist",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) LeaveWaitlist(ctx context.Context, vk_id int, role string) error {
+	query := sqlf.DeleteFrom("waitlist").
+		Where("vk_id = ?", vk_id).
+		Where("role = ?", role)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to leave waitlist",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+// the first waiting user of every role which is available and not reserved
+func (s *SQLite) WaitlistToOffer(ctx context.Context) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+
+	query := sqlf.From("waitlist_details").
+		Bind(&WaitlistEntry{}).
+		Where(`id IN (
+			SELECT min(id) FROM waitlist
+			WHERE offer_deadline IS NULL
+				AND role IN (SELECT name FROM available_roles)
+				AND role NOT IN (SELECT role FROM reservations)
+			GROUP BY role)`).
+		OrderBy("id")
+
+	err := s.db.Select(ctx, &entries, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get waitlist to offer",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return entries, nil
+}
+
+func (s *SQLite) OfferWaitlist(ctx context.Context, id int, deadline time.Time) error {
+	query := sqlf.Update("waitlist").
+		Set("offer_deadline", deadline).
+		Where("id = ?", id)
+
+	_, err := s.db.Exec(ctx, query.String(), query.Args()...)
+	if err != nil {
+		return zaperr.Wrap(err, "failed to offer waitlist role",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return nil
+}
+
+func (s *SQLite) ExpiredWaitlistOffers(ctx context.Context, deadline time.Time) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+
+	query := sqlf.From("waitlist_details").
+		Bind(&WaitlistEntry{}).
+		Where("unixepoch(?) - unixepoch(offer_deadline) > 0", deadline)
+
+	err := s.db.Select(ctx, &entries, query.String(), query.Args()...)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get expired waitlist offers",
+			zap.String("query", query.String()),
+			zap.Any("args", query.Args()))
+	}
+
+	return entries, nil
+}
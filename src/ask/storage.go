@@ -0,0 +1,116 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Storage keeps state of ask. It only reads and writes data,
+// timezone and config are applied by Ask itself.
+type Storage interface {
+	// fn is executed with storage bound to transaction
+	Transaction(ctx context.Context, fn func(s Storage) error) error
+
+	IsAdmin(ctx context.Context, vk_id int) (bool, error)
+
+	// roles
+	Roles(ctx context.Context) ([]Role, error)
+	AvailableRoles(ctx context.Context) ([]Role, error)
+	RolesStartWith(ctx context.Context, prefix string) ([]Role, error)
+	AvailableRolesStartWith(ctx context.Context, prefix string) ([]Role, error)
+	Role(ctx context.Context, name string) (Role, error)
+	RolesDictionary(ctx context.Context) ([]Role, error)
+	MatchHashtags(ctx context.Context, hashtags []string) ([]MatchedHashtag, error)
+	ChangeAlbums(ctx context.Context, albums map[string]int) error
+	ChangeBoards(ctx context.Context, boards map[string]int) error
+
+	// reservations, user can have several ones of different roles
+	AddReservation(ctx context.Context, vk_id int, role string, introduction int, is_confirmed bool) error
+	AddConfirmedReservation(ctx context.Context, vk_id int, role string, introduction int, introduction_peer int, deadline time.Time) error
+	Reservation(ctx context.Context, vk_id int, role string) (*Reservation, error)
+	ReservationsByVkID(ctx context.Context, vk_id int) ([]Reservation, error)
+	ReservationsByStatus(ctx context.Context, status ReservationStatus) ([]Reservation, error)
+	ReservationsByRole(ctx context.Context, role string) ([]Reservation, error)
+	Reservations(ctx context.Context) ([]Reservation, error)
+	ConfirmReservation(ctx context.Context, vk_id int, role string, deadline time.Time) error
+	CompleteReservation(ctx context.Context, vk_id int, role string, greeting Urls, is_accepted bool) error
+	AcceptGreeting(ctx context.Context, vk_id int, role string) error
+	RejectGreeting(ctx context.Context, vk_id int, role string) error
+	// deleted reservations are kept in archive with outcome
+	DeleteReservation(ctx context.Context, vk_id int, role string, outcome ReservationOutcome, reason string) error
+	// reservations under review are kept
+	DeleteReservationByDeadline(ctx context.Context, deadline time.Time, reason string) error
+	DeleteReservationByRole(ctx context.Context, role string, outcome ReservationOutcome, reason string) error
+	ArchivedReservations(ctx context.Context, vk_id int) ([]ArchivedReservation, error)
+	ChangeReservationDeadlineByRole(ctx context.Context, role string, deadline time.Time) error
+	AddReservationReminder(ctx context.Context, vk_id int, role string, deadline time.Time, ahead time.Duration) (bool, error)
+
+	// reservations history is filled by database itself
+	ReservationsHistory(ctx context.Context) ([]ReservationHistoryRecord, error)
+
+	// metadata of images saved in store
+	AddImage(ctx context.Context, image Image) error
+	ImageByUrl(ctx context.Context, url string) (*Image, error)
+
+	// reservation extensions, only ones of current reservations are listed
+	AddReservationExtension(ctx context.Context, vk_id int, role string, days int, reason string) error
+	ReservationExtension(ctx context.Context, id int) (*ReservationExtension, error)
+	ReservationExtensionsByRole(ctx context.Context, role string) ([]ReservationExtension, error)
+	ReservationExtensionsByStatus(ctx context.Context, status ExtensionStatus) ([]ReservationExtension, error)
+	DecideReservationExtension(ctx context.Context, id int, status ExtensionStatus, admin int) error
+
+	// waitlist
+	WaitlistRoles(ctx context.Context) ([]Role, error)
+	WaitlistByVkID(ctx context.Context, vk_id int) ([]WaitlistEntry, error)
+	JoinWaitlist(ctx context.Context, vk_id int, role string) error
+	LeaveWaitlist(ctx context.Context, vk_id int, role string) error
+	WaitlistToOffer(ctx context.Context) ([]WaitlistEntry, error)
+	OfferWaitlist(ctx context.Context, id int, deadline time.Time) error
+	ExpiredWaitlistOffers(ctx context.Context, deadline time.Time) ([]WaitlistEntry, error)
+
+	// bans, expired ones are not listed
+	AddBan(ctx context.Context, vk_id int, reason string, until sql.NullTime, admin int) error
+	Ban(ctx context.Context, vk_id int, now time.Time) (*Ban, error)
+	Bans(ctx context.Context, now time.Time) ([]Ban, error)
+	DeleteBan(ctx context.Context, vk_id int) error
+
+	// members
+	Member(ctx context.Context, id int) (Member, error)
+	MemberByRole(ctx context.Context, role string) (Member, error)
+	MembersByVkID(ctx context.Context, vk_id int) ([]Member, error)
+	AddMember(ctx context.Context, vk_id int, role string) (int, error)
+
+	// deadlines
+	Deadline(ctx context.Context, member int) (Deadline, error)
+	DeadlineJournal(ctx context.Context, member int) ([]DeadlineEvent, error)
+	ChangeDeadline(ctx context.Context, member int, diff time.Duration, kind DeadlineCause, cause string) error
+
+	// points
+	PointsByVkID(ctx context.Context, vk_id int) (int, error)
+	HistoryPointsByVkID(ctx context.Context, vk_id int) ([]Points, error)
+
+	// polls
+	OngoingPolls(ctx context.Context) ([]OngoingPoll, error)
+	AddOngoingPoll(ctx context.Context, role string, post int) error
+	DeleteOngoingPoll(ctx context.Context, role string) error
+	// decisions are ordered from the latest
+	AddPollDecision(ctx context.Context, decision PollDecision) error
+	LastPollDecision(ctx context.Context, role string) (*PollDecision, error)
+	PollDecisions(ctx context.Context, limit int) ([]PollDecision, error)
+	PollDecisionsByRole(ctx context.Context, role string, limit int) ([]PollDecision, error)
+	PollDecisionsRoles(ctx context.Context, prefix string) ([]Role, error)
+	Polls(ctx context.Context) ([]Poll, error)
+	PendingPolls(ctx context.Context) ([]PendingPoll, error)
+	SavePollAnswers(ctx context.Context, poll_id int, answers []PollAnswer) error
+	LoadPollAnswer(ctx context.Context, poll_id int, answer_id int) (int, bool, error)
+	DeletePollAnswers(ctx context.Context, poll_id int) error
+
+	// schedule
+	Timeslots(ctx context.Context, kind TimeslotKind) ([]Timeslot, error)
+
+	// audit
+	AddAuditRecord(ctx context.Context, record AuditRecord) error
+	AuditByVkID(ctx context.Context, vk_id int, limit int) ([]AuditRecord, error)
+	AuditByRole(ctx context.Context, role string, limit int) ([]AuditRecord, error)
+}
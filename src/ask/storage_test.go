@@ -0,0 +1,1169 @@
+package ask
+
+import (
+	"ask-bot/src/ask/db"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leporo/sqlf"
+)
+
+// data which is not written through Storage
+type storageFixture struct {
+	Admins    []int
+	Roles     []Role
+	Points    []Points
+	Timeslots []Timeslot
+}
+
+var fixture = storageFixture{
+	Admins: []int{1},
+	Roles: []Role{
+		{Name: "alice", Hashtag: "#alice", ShownName: "Alice", AccusativeName: "Alice", CaptionName: "Alice"},
+		{Name: "bob", Hashtag: "#bob", ShownName: "Bob", AccusativeName: "Bob", CaptionName: "Bob"},
+		{Name: "carol", Hashtag: "#a_carol", ShownName: "Carol", AccusativeName: "Carol", CaptionName: "Carol"},
+	},
+	Points: []Points{
+		{VkID: 10, Diff: 5, Cause: "first", Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{VkID: 10, Diff: -2, Cause: "second", Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	},
+	Timeslots: []Timeslot{
+		{Kind: TimeslotKinds.Polls, Query: "* * *", TimePoints: "12:00"},
+		{Kind: TimeslotKinds.Answers, Query: "* * *", TimePoints: "18:00"},
+	},
+}
+
+// in-memory database migrated to schema.sql
+func memoryDB(t *testing.T) *db.DB {
+	name := strings.ReplaceAll(t.Name(), "/", "_")
+
+	d, err := db.NewDB(fmt.Sprintf("file:%s?mode=memory&cache=shared", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+
+	err = d.Init("../../schema.sql", false, migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func sqliteStorage(t *testing.T, f storageFixture) Storage {
+	d := memoryDB(t)
+
+	queries := []*sqlf.Stmt{}
+	for _, admin := range f.Admins {
+		queries = append(queries, sqlf.InsertInto("administration").
+			Set("vk_id", admin))
+	}
+	for _, role := range f.Roles {
+		queries = append(queries, sqlf.InsertInto("roles").
+			Set("name", role.Name).
+			Set("hashtag", role.Hashtag).
+			Set("shown_name", role.ShownName).
+			Set("accusative_name", role.AccusativeName).
+			Set("caption_name", role.CaptionName))
+	}
+	for _, points := range f.Points {
+		queries = append(queries, sqlf.InsertInto("points").
+			Set("vk_id", points.VkID).
+			Set("diff", points.Diff).
+			Set("cause", points.Cause).
+			Set("timestamp", points.Timestamp))
+	}
+	for _, timeslot := range f.Timeslots {
+		queries = append(queries, sqlf.InsertInto("schedule").
+			Set("kind", timeslot.Kind).
+			Set("query", timeslot.Query).
+			Set("time_points", timeslot.TimePoints))
+	}
+
+	for _, query := range queries {
+		_, err := d.Exec(context.Background(), query.String(), query.Args()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return NewSQLite(d)
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	testStorage(t, sqliteStorage)
+}
+
+// contract every Storage implementation should satisfy
+func testStorage(t *testing.T, create func(*testing.T, storageFixture) Storage) {
+	ctx := context.Background()
+
+	t.Run("Administration", func(t *testing.T) {
+		s := create(t, fixture)
+
+		for vk_id, expected := range map[int]bool{1: true, 2: false} {
+			is_admin, err := s.IsAdmin(ctx, vk_id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if is_admin != expected {
+				t.Errorf("wrong admin status of %d: %t", vk_id, is_admin)
+			}
+		}
+	})
+
+	t.Run("Roles", func(t *testing.T) {
+		s := create(t, fixture)
+
+		roles, err := s.Roles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roles) != len(fixture.Roles) {
+			t.Errorf("wrong count of roles: %v", roles)
+		}
+
+		role, err := s.Role(ctx, "bob")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if role.Hashtag != "#bob" {
+			t.Errorf("wrong role: %v", role)
+		}
+
+		roles, err = s.RolesStartWith(ctx, "Ca")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(roleNames(roles), []string{"carol"}) {
+			t.Errorf("wrong roles starts with: %v", roles)
+		}
+
+		roles, err = s.RolesDictionary(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(roleNames(roles), []string{"carol", "alice", "bob"}) {
+			t.Errorf("dictionary should be ordered by hashtag: %v", roleNames(roles))
+		}
+
+		matched, err := s.MatchHashtags(ctx, []string{"#BOB", "#nobody"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(matched) != 2 || matched[0].Role.String != "bob" || matched[1].Role.Valid {
+			t.Errorf("wrong matched hashtags: %v", matched)
+		}
+
+		err = s.ChangeAlbums(ctx, map[string]int{"alice": 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.ChangeBoards(ctx, map[string]int{"alice": 200})
+		if err != nil {
+			t.Fatal(err)
+		}
+		role, err = s.Role(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if role.Album.Int32 != 100 || role.Board.Int32 != 200 {
+			t.Errorf("albums and boards are not changed: %v", role)
+		}
+	})
+
+	t.Run("Reservations", func(t *testing.T) {
+		s := create(t, fixture)
+
+		reservation, err := s.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation != nil {
+			t.Fatalf("unexpected reservation: %v", reservation)
+		}
+
+		err = s.AddReservation(ctx, 10, "alice", 1000, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.AddReservation(ctx, 20, "bob", 2000, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reservation, err = s.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation == nil ||
+			reservation.Status != ReservationStatuses.UnderConsideration ||
+			reservation.Introduction != 1000 ||
+			reservation.Name != "alice" {
+			t.Fatalf("wrong reservation: %v", reservation)
+		}
+
+		deadline := time.Date(2030, 1, 1, 23, 59, 59, 0, time.UTC)
+		err = s.ConfirmReservation(ctx, 10, "alice", deadline)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reservations, err := s.ReservationsByStatus(ctx, ReservationStatuses.InProgress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 2 {
+			t.Fatalf("wrong reservations in progress: %v", reservations)
+		}
+		for _, r := range reservations {
+			if r.VkID == 10 && !r.Deadline.Time.Equal(deadline) {
+				t.Errorf("wrong deadline: %v", r.Deadline)
+			}
+		}
+
+		err = s.CompleteReservation(ctx, 10, "alice", Urls{"https://example.com/greeting.png"}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservation, err = s.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation.Status != ReservationStatuses.Done ||
+			!slices.Equal(reservation.Greeting, Urls{"https://example.com/greeting.png"}) {
+			t.Errorf("reservation is not completed: %v", reservation)
+		}
+
+		// only confirmed reservations have deadline
+		err = s.DeleteReservationByDeadline(ctx, deadline.Add(time.Second), "deadline is over")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservations, err = s.Reservations(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 1 || reservations[0].VkID != 20 {
+			t.Errorf("wrong reservations after deletion by deadline: %v", reservations)
+		}
+
+		err = s.DeleteReservationByRole(ctx, "bob", ReservationOutcomes.Accepted, "poll is won")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservations, err = s.Reservations(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 0 {
+			t.Errorf("wrong reservations after deletion by role: %v", reservations)
+		}
+
+		archive, err := s.ArchivedReservations(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive) != 1 ||
+			archive[0].Outcome != ReservationOutcomes.Expired ||
+			archive[0].Role != "alice" ||
+			archive[0].Introduction != 1000 ||
+			!archive[0].Deadline.Time.Equal(deadline) ||
+			!slices.Equal(archive[0].Greeting, Urls{"https://example.com/greeting.png"}) {
+			t.Errorf("expired reservation should be archived: %v", archive)
+		}
+
+		archive, err = s.ArchivedReservations(ctx, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive) != 1 || archive[0].Outcome != ReservationOutcomes.Accepted || archive[0].Reason != "poll is won" {
+			t.Errorf("reservation deleted by role should be archived: %v", archive)
+		}
+
+		// user can have many finished reservations
+		for _, outcome := range []ReservationOutcome{ReservationOutcomes.Cancelled, ReservationOutcomes.Declined} {
+			err = s.AddReservation(ctx, 30, "carol", 3000, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.DeleteReservation(ctx, 30, "carol", outcome, string(outcome))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		archive, err = s.ArchivedReservations(ctx, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive) != 2 || archive[0].Outcome != ReservationOutcomes.Declined || archive[0].IsConfirmed {
+			t.Errorf("archive should be ordered from the latest: %v", archive)
+		}
+	})
+
+	t.Run("SeveralReservations", func(t *testing.T) {
+		s := create(t, fixture)
+
+		for _, role := range []string{"bob", "alice"} {
+			err := s.AddReservation(ctx, 10, role, 1000, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the same role can be reserved only once
+		err := s.AddReservation(ctx, 10, "alice", 1000, true)
+		if err == nil {
+			t.Errorf("the same role should not be reserved twice")
+		}
+
+		err = s.AddReservation(ctx, 20, "alice", 2000, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservations, err := s.ReservationsByRole(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 2 || reservations[0].Name != "alice" || reservations[1].Name != "alice" {
+			t.Errorf("wrong reservations of role: %v", reservations)
+		}
+		err = s.DeleteReservation(ctx, 20, "alice", ReservationOutcomes.Cancelled, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reservations, err = s.ReservationsByVkID(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 2 {
+			t.Fatalf("wrong reservations: %v", reservations)
+		}
+
+		err = s.CompleteReservation(ctx, 10, "bob", Urls{"https://example.com/greeting.png"}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.DeleteReservation(ctx, 10, "alice", ReservationOutcomes.Cancelled, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reservations, err = s.ReservationsByVkID(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reservations) != 1 ||
+			reservations[0].Name != "bob" ||
+			reservations[0].Status != ReservationStatuses.Done {
+			t.Errorf("only the other reservation should be left: %v", reservations)
+		}
+	})
+
+	t.Run("GreetingReview", func(t *testing.T) {
+		s := create(t, fixture)
+
+		deadline := time.Date(2030, 1, 1, 23, 59, 59, 0, time.UTC)
+
+		err := s.AddReservation(ctx, 10, "alice", 100, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.ConfirmReservation(ctx, 10, "alice", deadline)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status := func(expected ReservationStatus) {
+			t.Helper()
+
+			reservation, err := s.Reservation(ctx, 10, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reservation.Status != expected {
+				t.Errorf("wrong status: %s != %s", reservation.Status, expected)
+			}
+			if !reservation.Deadline.Time.Equal(deadline) {
+				t.Errorf("deadline should be kept: %v", reservation.Deadline)
+			}
+		}
+
+		err = s.CompleteReservation(ctx, 10, "alice", Urls{"https://example.com/greeting.png"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status(ReservationStatuses.UnderReview)
+
+		// greeting under review is not expired
+		err = s.DeleteReservationByDeadline(ctx, deadline.Add(time.Second), "deadline is over")
+		if err != nil {
+			t.Fatal(err)
+		}
+		status(ReservationStatuses.UnderReview)
+
+		// greeting under review is not polled
+		polls, err := s.PendingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(polls) != 0 {
+			t.Errorf("greeting under review should not be polled: %v", polls)
+		}
+
+		err = s.RejectGreeting(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		status(ReservationStatuses.InProgress)
+
+		err = s.CompleteReservation(ctx, 10, "alice", Urls{"https://example.com/greeting.png"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.AcceptGreeting(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		status(ReservationStatuses.Done)
+
+		polls, err = s.PendingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(polls) != 1 {
+			t.Errorf("accepted greeting should be polled: %v", polls)
+		}
+	})
+
+	t.Run("ReservationsHistory", func(t *testing.T) {
+		s := create(t, fixture)
+
+		err := s.AddReservation(ctx, 10, "alice", 100, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.ConfirmReservation(ctx, 10, "alice", time.Date(2030, 1, 1, 23, 59, 59, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// status is not changed
+		err = s.ChangeReservationDeadlineByRole(ctx, "alice", time.Date(2030, 1, 2, 23, 59, 59, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.DeleteReservation(ctx, 10, "alice", ReservationOutcomes.Cancelled, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := s.ReservationsHistory(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var statuses []string
+		for _, r := range records {
+			statuses = append(statuses, r.Status)
+		}
+
+		expected := []string{"Under Consideration", "In Progress", "Cancelled"}
+		if !slices.Equal(statuses, expected) {
+			t.Errorf("wrong history: %v != %v", statuses, expected)
+		}
+	})
+
+	t.Run("Images", func(t *testing.T) {
+		s := create(t, fixture)
+
+		image := Image{
+			Url:         "https://example.com/greeting.png",
+			Hash:        "first",
+			ContentType: "image/png",
+			Size:        10,
+		}
+
+		err := s.AddImage(ctx, image)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// image of known url is kept
+		err = s.AddImage(ctx, Image{Url: image.Url, Hash: "second", ContentType: "image/png", Size: 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		saved, err := s.ImageByUrl(ctx, image.Url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved == nil || saved.Hash != image.Hash || saved.Size != image.Size {
+			t.Errorf("wrong image: %v", saved)
+		}
+
+		saved, err = s.ImageByUrl(ctx, "https://example.com/unknown.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved != nil {
+			t.Errorf("unknown image is found: %v", saved)
+		}
+	})
+
+	t.Run("ReservationReminders", func(t *testing.T) {
+		s := create(t, fixture)
+
+		deadline := time.Date(2030, 1, 1, 23, 59, 59, 0, time.UTC)
+
+		for _, expected := range []bool{true, false} {
+			added, err := s.AddReservationReminder(ctx, 10, "alice", deadline, 6*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != expected {
+				t.Errorf("reminder should be added only once: %t", added)
+			}
+		}
+
+		// extended deadline is reminded again
+		added, err := s.AddReservationReminder(ctx, 10, "alice", deadline.Add(24*time.Hour), 6*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !added {
+			t.Error("reminder of new deadline should be added")
+		}
+	})
+
+	t.Run("ReservationExtensions", func(t *testing.T) {
+		s := create(t, fixture)
+
+		err := s.AddReservation(ctx, 10, "alice", 1000, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.AddReservationExtension(ctx, 10, "alice", 3, "exams")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		extensions, err := s.ReservationExtensionsByStatus(ctx, ExtensionStatuses.Pending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(extensions) != 1 ||
+			extensions[0].Role != "alice" ||
+			extensions[0].Days != 3 ||
+			extensions[0].Reason != "exams" ||
+			extensions[0].Admin.Valid {
+			t.Fatalf("wrong pending extensions: %v", extensions)
+		}
+
+		extension, err := s.ReservationExtension(ctx, extensions[0].Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if extension == nil || extension.VkID != 10 {
+			t.Errorf("wrong extension: %v", extension)
+		}
+
+		err = s.DecideReservationExtension(ctx, extensions[0].Id, ExtensionStatuses.Approved, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		extensions, err = s.ReservationExtensionsByRole(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(extensions) != 1 ||
+			extensions[0].Status != ExtensionStatuses.Approved ||
+			extensions[0].Admin.Int32 != 1 ||
+			!extensions[0].Decided.Valid {
+			t.Errorf("extension is not decided: %v", extensions)
+		}
+
+		deadline := time.Date(2030, 1, 4, 23, 59, 59, 0, time.UTC)
+		err = s.ChangeReservationDeadlineByRole(ctx, "alice", deadline)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reservation, err := s.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reservation.Deadline.Time.Equal(deadline) {
+			t.Errorf("deadline is not changed: %v", reservation.Deadline)
+		}
+
+		// extensions of finished reservation are not listed
+		err = s.DeleteReservation(ctx, 10, "alice", ReservationOutcomes.Cancelled, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		extensions, err = s.ReservationExtensionsByRole(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(extensions) != 0 {
+			t.Errorf("extensions of finished reservation are listed: %v", extensions)
+		}
+	})
+
+	t.Run("Waitlist", func(t *testing.T) {
+		s := create(t, fixture)
+
+		err := s.AddReservation(ctx, 10, "alice", 1000, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		roles, err := s.WaitlistRoles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(roleNames(roles), []string{"alice"}) {
+			t.Errorf("only reserved role should be waited for: %v", roles)
+		}
+
+		for _, vk_id := range []int{20, 30} {
+			err = s.JoinWaitlist(ctx, vk_id, "alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err = s.JoinWaitlist(ctx, 20, "alice")
+		if err == nil {
+			t.Error("user should not join waitlist twice")
+		}
+
+		// role is still reserved
+		entries, err := s.WaitlistToOffer(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("reserved role should not be offered: %v", entries)
+		}
+
+		err = s.DeleteReservation(ctx, 10, "alice", ReservationOutcomes.Cancelled, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err = s.WaitlistToOffer(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].VkID != 20 || entries[0].Name != "alice" {
+			t.Fatalf("the first user in line should be offered: %v", entries)
+		}
+
+		deadline := time.Now().UTC().Add(time.Hour)
+		err = s.OfferWaitlist(ctx, entries[0].Id, deadline)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// offered role is held
+		roles, err = s.AvailableRoles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(roleNames(roles), "alice") {
+			t.Errorf("offered role should not be available: %v", roles)
+		}
+		entries, err = s.WaitlistToOffer(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("role should not be offered twice: %v", entries)
+		}
+
+		entries, err = s.WaitlistByVkID(ctx, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || !entries[0].OfferDeadline.Valid || !entries[0].OfferDeadline.Time.Equal(deadline) {
+			t.Errorf("wrong waitlist of user: %v", entries)
+		}
+
+		entries, err = s.ExpiredWaitlistOffers(ctx, deadline.Add(time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].VkID != 20 {
+			t.Errorf("wrong expired offers: %v", entries)
+		}
+
+		err = s.LeaveWaitlist(ctx, 20, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entries, err = s.WaitlistToOffer(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].VkID != 30 {
+			t.Errorf("next user in line should be offered: %v", entries)
+		}
+	})
+
+	t.Run("Bans", func(t *testing.T) {
+		s := create(t, fixture)
+
+		now := time.Now().UTC()
+		until := sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+
+		err := s.AddBan(ctx, 10, "no-show", until, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.AddBan(ctx, 20, "rules", sql.NullTime{}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ban, err := s.Ban(ctx, 10, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ban == nil || ban.Reason != "no-show" || !ban.Until.Valid || !ban.Until.Time.Equal(until.Time) || ban.Admin != 1 {
+			t.Fatalf("wrong ban: %v", ban)
+		}
+
+		// expired ban is not active
+		ban, err = s.Ban(ctx, 10, now.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ban != nil {
+			t.Errorf("expired ban should not be active: %v", ban)
+		}
+
+		bans, err := s.Bans(ctx, now.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bans) != 1 || bans[0].VkID != 20 || bans[0].Until.Valid {
+			t.Errorf("only permanent ban should be active: %v", bans)
+		}
+
+		// new ban replaces previous one
+		err = s.AddBan(ctx, 10, "again", sql.NullTime{}, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ban, err = s.Ban(ctx, 10, now.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ban == nil || ban.Reason != "again" || ban.Until.Valid {
+			t.Errorf("ban should be replaced: %v", ban)
+		}
+
+		err = s.DeleteBan(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ban, err = s.Ban(ctx, 10, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ban != nil {
+			t.Errorf("lifted ban should be deleted: %v", ban)
+		}
+	})
+
+	t.Run("MembersAndDeadlines", func(t *testing.T) {
+		s := create(t, fixture)
+
+		member, err := s.AddMember(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := s.MemberByRole(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Id != member || m.VkID != 10 || m.Status != MemberStatuses.Active {
+			t.Errorf("wrong member by role: %v", m)
+		}
+
+		m, err = s.Member(ctx, member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Name != "alice" || m.VkID != 10 {
+			t.Errorf("wrong member by id: %v", m)
+		}
+
+		initial, err := s.Deadline(ctx, member)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = s.ChangeDeadline(ctx, member, 24*time.Hour, DeadlineCauses.Delay, "delay")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deadline, err := s.Deadline(ctx, member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := deadline.Deadline.Time().Sub(initial.Deadline.Time()); diff != 24*time.Hour {
+			t.Errorf("wrong deadline difference: %s", diff)
+		}
+
+		journal, err := s.DeadlineJournal(ctx, member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(journal) != 2 {
+			t.Errorf("wrong deadline journal: %v", journal)
+		}
+
+		members, err := s.MembersByVkID(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(members) != 1 || !members[0].Deadline.Time().Equal(deadline.Deadline.Time()) {
+			t.Errorf("wrong members by vk id: %v", members)
+		}
+
+		roles, err := s.AvailableRoles(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(roleNames(roles), "alice") {
+			t.Errorf("role of member should not be available: %v", roleNames(roles))
+		}
+
+		roles, err = s.AvailableRolesStartWith(ctx, "A")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roles) != 0 {
+			t.Errorf("role of member should not be available: %v", roleNames(roles))
+		}
+	})
+
+	t.Run("Points", func(t *testing.T) {
+		s := create(t, fixture)
+
+		points, err := s.PointsByVkID(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if points != 3 {
+			t.Errorf("wrong points: %d", points)
+		}
+
+		points, err = s.PointsByVkID(ctx, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if points != 0 {
+			t.Errorf("points without history should be zero: %d", points)
+		}
+
+		history, err := s.HistoryPointsByVkID(ctx, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 || history[0].Cause != "second" {
+			t.Errorf("history should be ordered from the latest: %v", history)
+		}
+	})
+
+	t.Run("Polls", func(t *testing.T) {
+		s := create(t, fixture)
+
+		for _, r := range []struct {
+			vk_id int
+			role  string
+		}{{30, "alice"}, {10, "alice"}, {20, "bob"}} {
+			err := s.AddReservation(ctx, r.vk_id, r.role, 0, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.CompleteReservation(ctx, r.vk_id, r.role, Urls{fmt.Sprintf("https://example.com/%d.png", r.vk_id)}, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pending, err := s.PendingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 2 || pending[0].Name != "alice" || pending[1].Name != "bob" {
+			t.Fatalf("wrong pending polls: %v", pending)
+		}
+		if pending[0].Count != 2 || !slices.Equal(pending[0].Participants, VkIDs{10, 30}) {
+			t.Errorf("wrong participants of poll: %v", pending[0])
+		}
+		if !slices.Equal(pending[1].Participants, VkIDs{20}) {
+			t.Errorf("participants should not be mixed between polls: %v", pending[1])
+		}
+		if len(pending[0].Greetings) != 2 || pending[0].Greetings[30][0] != "https://example.com/30.png" {
+			t.Errorf("wrong greetings of poll: %v", pending[0].Greetings)
+		}
+
+		err = s.AddOngoingPoll(ctx, "alice", 500)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ongoing, err := s.OngoingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ongoing) != 1 || ongoing[0].Post != 500 {
+			t.Errorf("wrong ongoing polls: %v", ongoing)
+		}
+
+		pending, err = s.PendingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 || pending[0].Name != "bob" {
+			t.Errorf("ongoing poll should not be pending: %v", pending)
+		}
+
+		polls, err := s.Polls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(polls) != 2 {
+			t.Fatalf("wrong polls: %v", polls)
+		}
+		for _, poll := range polls {
+			if (poll.Name == "alice") != poll.Post.Valid {
+				t.Errorf("only ongoing poll has post: %v", poll)
+			}
+		}
+
+		reservation, err := s.Reservation(ctx, 10, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reservation.Status != ReservationStatuses.Poll || reservation.Poll.Int32 != 500 {
+			t.Errorf("reservation should be on poll: %v", reservation)
+		}
+
+		err = s.SavePollAnswers(ctx, 7, []PollAnswer{{ID: 1, Value: 10}, {ID: 2, Value: -1}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, ok, err := s.LoadPollAnswer(ctx, 7, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok || value != -1 {
+			t.Errorf("wrong poll answer: %d", value)
+		}
+
+		_, ok, err = s.LoadPollAnswer(ctx, 7, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Error("unknown poll answer should not be found")
+		}
+
+		err = s.DeleteOngoingPoll(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ongoing, err = s.OngoingPolls(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ongoing) != 0 {
+			t.Errorf("ongoing poll should be deleted: %v", ongoing)
+		}
+
+		decision, err := s.LastPollDecision(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision != nil {
+			t.Errorf("there should be no decision: %v", decision)
+		}
+
+		for _, d := range []PollDecision{
+			{Role: "alice", Post: 500, Kind: PollDecisionKinds.Runoff, Results: PollResults{{10, 1}, {30, 1}}},
+			{Role: "bob", Post: 501, Kind: PollDecisionKinds.Winner, Winner: sql.NullInt32{Int32: 20, Valid: true}, Results: PollResults{{20, 3}}},
+		} {
+			err = s.AddPollDecision(ctx, d)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		decision, err = s.LastPollDecision(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision == nil || decision.Kind != PollDecisionKinds.Runoff || !slices.Equal(decision.Results, PollResults{{10, 1}, {30, 1}}) {
+			t.Errorf("wrong last decision: %v", decision)
+		}
+
+		decisions, err := s.PollDecisions(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(decisions) != 1 || decisions[0].Role != "bob" || decisions[0].Winner.Int32 != 20 {
+			t.Errorf("the latest decision should be first: %v", decisions)
+		}
+
+		err = s.DeletePollAnswers(ctx, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, ok, err = s.LoadPollAnswer(ctx, 7, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Errorf("poll answers should be deleted")
+		}
+
+		err = s.AddPollDecision(ctx, PollDecision{
+			Role:         "alice",
+			Post:         502,
+			Participants: VkIDs{10, 30},
+			Kind:         PollDecisionKinds.Winner,
+			Winner:       sql.NullInt32{Int32: 10, Valid: true},
+			Results:      PollResults{{10, 2}, {30, 0}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		archive, err := s.PollDecisionsByRole(ctx, "alice", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive) != 2 || archive[0].Post != 502 || archive[0].WinnerID() != 10 ||
+			!slices.Equal(archive[0].Participants, VkIDs{10, 30}) || len(archive[1].Participants) != 0 {
+			t.Errorf("wrong decisions of role: %v", archive)
+		}
+
+		roles, err := s.PollDecisionsRoles(ctx, "al")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roles) != 1 || roles[0].Name != "alice" {
+			t.Errorf("only role with ended polls should be returned: %v", roles)
+		}
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		s := create(t, fixture)
+
+		records := []AuditRecord{
+			{
+				ActorKind: ActorKinds.User,
+				Actor:     sql.NullInt32{Int32: 10, Valid: true},
+				Action:    AuditActions.AddReservation,
+				VkID:      sql.NullInt32{Int32: 10, Valid: true},
+				Role:      sql.NullString{String: "alice", Valid: true},
+				After:     sql.NullString{String: `{"vk_id":10}`, Valid: true},
+			},
+			{
+				ActorKind: ActorKinds.Admin,
+				Actor:     sql.NullInt32{Int32: 1, Valid: true},
+				Action:    AuditActions.DeclineReservation,
+				VkID:      sql.NullInt32{Int32: 10, Valid: true},
+				Role:      sql.NullString{String: "alice", Valid: true},
+				Before:    sql.NullString{String: `{"vk_id":10}`, Valid: true},
+			},
+			{
+				ActorKind: ActorKinds.Watcher,
+				Action:    AuditActions.ChangeAlbum,
+				Role:      sql.NullString{String: "bob", Valid: true},
+			},
+		}
+
+		for _, record := range records {
+			err := s.AddAuditRecord(ctx, record)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		by_vk_id, err := s.AuditByVkID(ctx, 10, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(by_vk_id) != 2 || by_vk_id[0].Action != AuditActions.DeclineReservation {
+			t.Errorf("audit should be ordered from the latest: %v", by_vk_id)
+		}
+		if by_vk_id[0].Actor.Int32 != 1 || by_vk_id[0].After.Valid {
+			t.Errorf("wrong audit record: %v", by_vk_id[0])
+		}
+
+		// admin is found as actor
+		by_actor, err := s.AuditByVkID(ctx, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(by_actor) != 1 {
+			t.Errorf("wrong audit records by actor: %v", by_actor)
+		}
+
+		limited, err := s.AuditByVkID(ctx, 10, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(limited) != 1 {
+			t.Errorf("audit records should be limited: %v", limited)
+		}
+
+		by_role, err := s.AuditByRole(ctx, "bob", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(by_role) != 1 || by_role[0].ActorKind != ActorKinds.Watcher || by_role[0].Actor.Valid {
+			t.Errorf("wrong audit records by role: %v", by_role)
+		}
+	})
+
+	t.Run("Timeslots", func(t *testing.T) {
+		s := create(t, fixture)
+
+		timeslots, err := s.Timeslots(ctx, TimeslotKinds.Polls)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(timeslots) != 1 || timeslots[0].TimePoints != "12:00" {
+			t.Errorf("wrong timeslots: %v", timeslots)
+		}
+	})
+}
+
+func roleNames(roles []Role) []string {
+	names := make([]string, len(roles))
+	for i := range roles {
+		names[i] = roles[i].Name
+	}
+	return names
+}
@@ -0,0 +1,166 @@
+package ask
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type WaitlistEntry struct {
+	Id            int          `db:"id"`
+	VkID          int          `db:"vk_id"`
+	OfferDeadline sql.NullTime `db:"offer_deadline"`
+
+	Role
+}
+
+// roles which are reserved or taken, except members' ones
+func (a *Ask) WaitlistRoles(ctx context.Context) ([]Role, error) {
+	return a.storage.WaitlistRoles(ctx)
+}
+
+func (a *Ask) WaitlistByVkID(ctx context.Context, vk_id int) ([]WaitlistEntry, error) {
+	entries, err := a.storage.WaitlistByVkID(ctx, vk_id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].OfferDeadline.Time = entries[i].OfferDeadline.Time.Add(a.timezone)
+	}
+
+	return entries, nil
+}
+
+// offers of user which are not expired yet, expired ones could be not deleted yet
+func (a *Ask) WaitlistOffers(ctx context.Context, vk_id int) ([]WaitlistEntry, error) {
+	entries, err := a.storage.WaitlistByVkID(ctx, vk_id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	var offers []WaitlistEntry
+	for i := range entries {
+		if !entries[i].OfferDeadline.Valid || !entries[i].OfferDeadline.Time.After(now) {
+			continue
+		}
+
+		entries[i].OfferDeadline.Time = entries[i].OfferDeadline.Time.Add(a.timezone)
+		offers = append(offers, entries[i])
+	}
+
+	return offers, nil
+}
+
+func (a *Ask) JoinWaitlist(ctx context.Context, actor Actor, vk_id int, role string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.storage.JoinWaitlist(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.JoinWaitlist, vk_id, role, nil, nil)
+	})
+}
+
+func (a *Ask) LeaveWaitlist(ctx context.Context, actor Actor, vk_id int, role string) error {
+	return a.WithTx(ctx, func(tx *Ask) error {
+		err := tx.storage.LeaveWaitlist(ctx, vk_id, role)
+		if err != nil {
+			return err
+		}
+
+		return tx.audit(ctx, actor, AuditActions.LeaveWaitlist, vk_id, role, nil, nil)
+	})
+}
+
+// The first user in line for every free role gets offer to claim it.
+// Role is hidden from available roles until offer deadline.
+func (a *Ask) OfferWaitlistRoles(ctx context.Context, actor Actor) ([]WaitlistEntry, error) {
+	deadline := time.Now().UTC().Add(a.config.WaitlistOfferDuration)
+
+	var offered []WaitlistEntry
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		entries, err := tx.storage.WaitlistToOffer(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			before := entries[i]
+
+			err := tx.storage.OfferWaitlist(ctx, entries[i].Id, deadline)
+			if err != nil {
+				return err
+			}
+
+			entries[i].OfferDeadline = sql.NullTime{Time: deadline, Valid: true}
+
+			err = tx.audit(ctx, actor,
+				AuditActions.OfferWaitlist,
+				entries[i].VkID,
+				entries[i].Name,
+				&before,
+				&entries[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		offered = entries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range offered {
+		offered[i].OfferDeadline.Time = offered[i].OfferDeadline.Time.Add(a.timezone)
+	}
+
+	return offered, nil
+}
+
+// users with expired offers lose their place in line
+func (a *Ask) DeleteExpiredWaitlistOffers(ctx context.Context, actor Actor, now time.Time) ([]WaitlistEntry, error) {
+	var expired []WaitlistEntry
+
+	err := a.WithTx(ctx, func(tx *Ask) error {
+		entries, err := tx.storage.ExpiredWaitlistOffers(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			err := tx.storage.LeaveWaitlist(ctx, entries[i].VkID, entries[i].Name)
+			if err != nil {
+				return err
+			}
+
+			err = tx.audit(ctx, actor,
+				AuditActions.LeaveWaitlist,
+				entries[i].VkID,
+				entries[i].Name,
+				&entries[i],
+				nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		expired = entries
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range expired {
+		expired[i].OfferDeadline.Time = expired[i].OfferDeadline.Time.Add(a.timezone)
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,118 @@
+package ask
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitlistOfferIsClaimed(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration:   24 * time.Hour,
+		WaitlistOfferDuration: time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.JoinWaitlist(ctx, UserActor(20), 20, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// role is offered at once, not by watcher
+	offered, err := a.DeclineReservation(ctx, AdminActor(1), 10, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 1 || offered[0].VkID != 20 || !offered[0].OfferDeadline.Valid {
+		t.Fatalf("declined role should be offered: %v", offered)
+	}
+
+	offered, err = a.OfferWaitlistRoles(ctx, WatcherActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 0 {
+		t.Fatalf("role should not be offered twice: %v", offered)
+	}
+
+	offers, err := a.WaitlistOffers(ctx, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 1 || offers[0].Name != "alice" {
+		t.Errorf("offer should be active: %v", offers)
+	}
+
+	err = a.AddReservation(ctx, UserActor(20), 20, "alice", 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := a.WaitlistByVkID(ctx, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("claimed role should leave waitlist: %v", entries)
+	}
+
+	expired, err := a.DeleteExpiredWaitlistOffers(ctx, WatcherActor, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 0 {
+		t.Errorf("claimed offer should not expire: %v", expired)
+	}
+}
+
+func TestWaitlistOffersExpired(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewWithStorage(&Config{
+		ReservationDuration:   24 * time.Hour,
+		WaitlistOfferDuration: time.Hour,
+	}, sqliteStorage(t, fixture))
+
+	err := a.AddReservation(ctx, UserActor(10), 10, "alice", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.JoinWaitlist(ctx, UserActor(20), 20, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offered, err := a.DeclineReservation(ctx, AdminActor(1), 10, "alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offered) != 1 {
+		t.Fatalf("declined role should be offered: %v", offered)
+	}
+
+	offers, err := a.WaitlistOffers(ctx, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 1 || offers[0].Name != "alice" {
+		t.Errorf("offer should be active: %v", offers)
+	}
+
+	// offer is expired but is not deleted by watcher yet
+	err = a.storage.OfferWaitlist(ctx, offered[0].Id, time.Now().UTC().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offers, err = a.WaitlistOffers(ctx, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 0 {
+		t.Errorf("expired offer should not be shown: %v", offers)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"ask-bot/src/chatbot/states"
 	"ask-bot/src/datatypes/stack"
 	"ask-bot/src/vk"
+	"context"
 	"time"
 
 	"github.com/SevereCloud/vksdk/v2/events"
@@ -73,8 +74,8 @@ func (c *Chat) Notify(controls *states.Controls, message *vk.MessageParams) erro
 	return c.tryNotify(controls)
 }
 
-func (c *Chat) Work(controls *states.Controls, input interface{}, init bool) error {
-	err := c.work(controls, input, init)
+func (c *Chat) Work(ctx context.Context, controls *states.Controls, input interface{}, init bool) error {
+	err := c.work(ctx, controls, input, init)
 	if err != nil {
 		return err
 	}
@@ -82,11 +83,11 @@ func (c *Chat) Work(controls *states.Controls, input interface{}, init bool) err
 	return c.tryNotify(controls)
 }
 
-func (c *Chat) work(controls *states.Controls, input interface{}, existed bool) (err error) {
+func (c *Chat) work(ctx context.Context, controls *states.Controls, input interface{}, existed bool) (err error) {
 	if !existed {
-		err := c.stack.Peek().Entry(c.user, controls)
+		err := c.stack.Peek().Entry(ctx, c.user, controls)
 		if err != nil {
-			c.Reset(controls)
+			c.Reset(ctx, controls)
 			return err
 		}
 	}
@@ -101,16 +102,32 @@ func (c *Chat) work(controls *states.Controls, input interface{}, existed bool)
 			Attachments: event.Message.Attachments,
 		}
 
-		action, err = c.stack.Peek().NewMessage(c.user, controls, message)
+		if !states.ExpectsText(c.stack.Peek()) {
+			command, err := states.ParseCommand(message.Text)
+			if err != nil {
+				c.Reset(ctx, controls)
+				return err
+			}
+			if command != nil {
+				err = c.command(ctx, controls, command)
+				if err != nil {
+					c.Reset(ctx, controls)
+					return err
+				}
+				return nil
+			}
+		}
+
+		action, err = c.stack.Peek().NewMessage(ctx, c.user, controls, message)
 		if err != nil {
-			c.Reset(controls)
+			c.Reset(ctx, controls)
 			return err
 		}
 
 	case events.MessageEventObject:
 		payload, err := vk.UnmarshalPayload(event.Payload)
 		if err != nil {
-			c.Reset(controls)
+			c.Reset(ctx, controls)
 			return zaperr.Wrap(err, "failed to unmarshal payload",
 				zap.Any("payload", event.Payload))
 		}
@@ -123,30 +140,47 @@ func (c *Chat) work(controls *states.Controls, input interface{}, existed bool)
 			return nil
 		}
 
-		action, err = c.stack.Peek().KeyboardEvent(c.user, controls, payload)
+		action, err = c.stack.Peek().KeyboardEvent(ctx, c.user, controls, payload)
 		if err != nil {
-			c.Reset(controls)
+			c.Reset(ctx, controls)
 			return err
 		}
 	}
 
 	switch action.Kind() {
 	case states.Next:
-		err = c.next(controls, action.Next())
+		err = c.next(ctx, controls, action.Next())
 	case states.Exit:
-		err = c.exit(controls, action.Exit())
+		err = c.exit(ctx, controls, action.Exit())
 	}
 
 	if err != nil {
-		c.Reset(controls)
+		c.Reset(ctx, controls)
 		return err
 	}
 	return nil
 }
 
-func (c *Chat) next(controls *states.Controls, next states.State) error {
+// command is done from any state, then chat starts over from reset state
+func (c *Chat) command(ctx context.Context, controls *states.Controls, command *states.Command) error {
+	next, err := command.State(ctx, c.user, controls)
+	if err != nil {
+		return err
+	}
+
+	c.stack = stack.New[states.State](c.reset_state)
+
+	if next == nil {
+		_, err := c.stack.Peek().Back(ctx, c.user, controls, nil)
+		return err
+	}
+
+	return c.next(ctx, controls, next)
+}
+
+func (c *Chat) next(ctx context.Context, controls *states.Controls, next states.State) error {
 	c.stack.Push(next)
-	err := c.stack.Peek().Entry(c.user, controls)
+	err := c.stack.Peek().Entry(ctx, c.user, controls)
 	if err != nil {
 		return err
 	}
@@ -154,10 +188,10 @@ func (c *Chat) next(controls *states.Controls, next states.State) error {
 	return nil
 }
 
-func (c *Chat) exit(controls *states.Controls, info *states.ExitInfo) error {
+func (c *Chat) exit(ctx context.Context, controls *states.Controls, info *states.ExitInfo) error {
 	c.stack.Pop()
 
-	action, err := c.stack.Peek().Back(c.user, controls, info)
+	action, err := c.stack.Peek().Back(ctx, c.user, controls, info)
 	if err != nil {
 		return err
 	}
@@ -165,7 +199,7 @@ func (c *Chat) exit(controls *states.Controls, info *states.ExitInfo) error {
 	for action != nil {
 		switch action.Kind() {
 		case states.Next:
-			err := c.next(controls, action.Next())
+			err := c.next(ctx, controls, action.Next())
 			if err != nil {
 				return err
 			}
@@ -174,7 +208,7 @@ func (c *Chat) exit(controls *states.Controls, info *states.ExitInfo) error {
 		case states.Exit:
 			c.stack.Pop()
 
-			action, err = c.stack.Peek().Back(c.user, controls, action.Exit())
+			action, err = c.stack.Peek().Back(ctx, c.user, controls, action.Exit())
 			if err != nil {
 				return err
 			}
@@ -207,11 +241,11 @@ func (c *Chat) Finish(controls *states.Controls) {
 	}
 }
 
-func (c *Chat) Reset(controls *states.Controls) {
+func (c *Chat) Reset(ctx context.Context, controls *states.Controls) {
 	c.stack = stack.New[states.State](c.reset_state)
 
 	message := "В ходе работы произошла ошибка. Пожалуйста, попробуйте еще раз попозже."
 	controls.Vk.SendMessage(c.user.Id, message, "", nil)
 
-	c.stack.Peek().Back(c.user, controls, nil)
+	c.stack.Peek().Back(ctx, c.user, controls, nil)
 }
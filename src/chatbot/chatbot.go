@@ -61,7 +61,7 @@ func (bot *Chatbot) ListenNotification(ctx context.Context, wg *sync.WaitGroup)
 	for {
 		select {
 		case message := <-bot.controls.Notify:
-			err := bot.NotifyChat(message)
+			err := bot.NotifyChat(ctx, message)
 			if err != nil {
 				bot.log.Errorw("error occured while try to notify",
 					"message", message,
@@ -74,14 +74,14 @@ func (bot *Chatbot) ListenNotification(ctx context.Context, wg *sync.WaitGroup)
 	}
 }
 
-func (bot *Chatbot) NotifyChat(message *vk.MessageParams) error {
+func (bot *Chatbot) NotifyChat(ctx context.Context, message *vk.MessageParams) error {
 	chat, existed := bot.TakeChat(message.Id, &states.Init{
 		Silent: true,
 	})
 	defer bot.ReturnChat(message.Id)
 
 	if !existed {
-		chat.Work(bot.controls, nil, true)
+		chat.Work(ctx, bot.controls, nil, true)
 	}
 
 	err := chat.Notify(bot.controls, message)
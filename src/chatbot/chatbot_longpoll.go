@@ -59,7 +59,7 @@ func (bot *Chatbot) Work(ctx context.Context, user_id int, obj interface{}) erro
 	chat, existed := bot.TakeChat(user_id, &states.Init{})
 	defer bot.ReturnChat(user_id)
 
-	err := chat.Work(bot.controls, obj, existed)
+	err := chat.Work(ctx, bot.controls, obj, existed)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,7 @@ import (
 	"ask-bot/src/datatypes/form"
 	"ask-bot/src/datatypes/paginator"
 	"ask-bot/src/vk"
+	"context"
 	"errors"
 
 	"github.com/hori-ryota/zaperr"
@@ -25,11 +26,36 @@ func (state *Admin) options() []form.Option {
 			Label: "Брони",
 			Value: &AdminReservation{},
 		},
+		{
+			ID:    (&AdminBans{}).ID(),
+			Label: "Баны",
+			Value: &AdminBans{},
+		},
 		{
 			ID:    (&RolesList{}).ID(),
 			Label: "Список ролей",
 			Value: &RolesList{},
 		},
+		{
+			ID:    (&AdminFunnel{}).ID(),
+			Label: "Воронка броней",
+			Value: &AdminFunnel{},
+		},
+		{
+			ID:    (&AdminPollDecisions{}).ID(),
+			Label: "Решения опросов",
+			Value: &AdminPollDecisions{},
+		},
+		{
+			ID:    (&PollsArchive{}).ID(),
+			Label: "Архив опросов",
+			Value: &PollsArchive{},
+		},
+		{
+			ID:    (&AdminAudit{}).ID(),
+			Label: "Журнал действий",
+			Value: &AdminAudit{},
+		},
 	}
 }
 
@@ -54,8 +80,8 @@ func (state *Admin) updatePaginator() error {
 	return nil
 }
 
-func (state *Admin) Entry(user *User, c *Controls) error {
-	ok, err := c.Ask.IsAdmin(user.Id)
+func (state *Admin) Entry(ctx context.Context, user *User, c *Controls) error {
+	ok, err := c.Ask.IsAdmin(ctx, user.Id)
 	if err != nil {
 		return err
 	}
@@ -71,10 +97,10 @@ func (state *Admin) Entry(user *User, c *Controls) error {
 	return c.Vk.ChangeKeyboard(user.Id, vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
 }
 
-func (state *Admin) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *Admin) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
-func (state *Admin) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *Admin) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "options":
 		option, err := state.paginator.Object(payload.Value)
@@ -103,6 +129,6 @@ func (state *Admin) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackP
 	return nil, nil
 }
 
-func (state *Admin) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *Admin) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
@@ -0,0 +1,89 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+	"strconv"
+	"strings"
+)
+
+const MaxLengthAudit int = 20
+
+type AdminAudit struct{}
+
+func (state *AdminAudit) ID() string {
+	return "admin_audit"
+}
+
+func (state *AdminAudit) Entry(ctx context.Context, user *User, c *Controls) error {
+	message, err := ts.ParseTemplate(
+		ts.MsgAdminAudit,
+		ts.MsgAdminAuditData{},
+	)
+	if err != nil {
+		return err
+	}
+
+	buttons := [][]vk.Button{
+		{
+			{
+				Label: "Назад",
+				Color: vk.NegativeColor,
+
+				Command: "back",
+			},
+		},
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), buttons),
+		nil)
+	return err
+}
+
+// message is vk id (with or without @id) or name of role
+func (state *AdminAudit) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	text := strings.TrimSpace(message.Text)
+
+	var records []ask.AuditRecord
+	var err error
+
+	vk_id, atoi_err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(text, "@"), "id"))
+	if atoi_err == nil {
+		records, err = c.Ask.AuditByVkID(ctx, vk_id, MaxLengthAudit)
+	} else {
+		records, err = c.Ask.AuditByRole(ctx, text, MaxLengthAudit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := ts.ParseTemplate(
+		ts.MsgAdminAuditRecords,
+		ts.MsgAdminAuditRecordsData{
+			Records: records,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Vk.SendMessage(user.Id, answer, "", nil)
+	return nil, err
+}
+
+func (state *AdminAudit) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "back":
+		return NewActionExit(nil), nil
+	}
+
+	return nil, nil
+}
+
+func (state *AdminAudit) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
+}
@@ -0,0 +1,323 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	"ask-bot/src/datatypes/dict"
+	"ask-bot/src/datatypes/form"
+	"ask-bot/src/datatypes/form/check"
+	"ask-bot/src/datatypes/form/extrude"
+	"ask-bot/src/datatypes/paginator"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+	"strconv"
+	"strings"
+)
+
+// Banned users can not make reservations, ban is lifted by admin or expires.
+type AdminBans struct {
+	paginator *paginator.Paginator[form.Option]
+}
+
+func (state *AdminBans) ID() string {
+	return "admin_bans"
+}
+
+func (state *AdminBans) options(num_bans int) (options []form.Option) {
+	options = append(options, form.Option{
+		ID:    "ban",
+		Label: "Забанить",
+		Color: vk.PrimaryColor,
+	})
+
+	if num_bans > 0 {
+		options = append(options, form.Option{
+			ID:    "lift",
+			Label: "Снять бан",
+			Color: vk.SecondaryColor,
+		})
+	}
+
+	return
+}
+
+func (state *AdminBans) Entry(ctx context.Context, user *User, c *Controls) error {
+	bans, err := c.Ask.Bans(ctx)
+	if err != nil {
+		return err
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgAdminBans,
+		ts.MsgAdminBansData{
+			Bans: bans,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	config := &paginator.Config[form.Option]{
+		Command: "options",
+
+		ToLabel: form.OptionToLabel,
+		ToColor: form.OptionToColor,
+		ToValue: form.OptionToValue,
+	}
+
+	state.paginator = paginator.New(state.options(len(bans)),
+		config.MustBuild())
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), state.paginator.Buttons()),
+		nil)
+	return err
+}
+
+func (state *AdminBans) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	return nil, nil
+}
+
+func (state *AdminBans) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "options":
+		option, err := state.paginator.Object(payload.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch option.ID {
+		case "ban":
+			// profile link is resolved again on ban
+			profile := form.Field{
+				Name: "profile",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Отправьте id пользователя или ссылку на его страницу."},
+					nil),
+				ExtrudeMessage: extrude.Text,
+				Check: func(value interface{}) (*check.Result, error) {
+					profile, ok := value.(string)
+					if !ok || len(profile) == 0 {
+						return check.NewResult("Поле обязательно для заполнения."), nil
+					}
+
+					vk_id, err := c.Vk.ResolveUser(profile)
+					if err != nil {
+						return nil, err
+					}
+					if vk_id == 0 {
+						return check.NewResult("Пользователь не найден."), nil
+					}
+
+					return nil, nil
+				},
+			}
+
+			// zero days is permanent ban
+			days := form.Field{
+				Name: "days",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Отправьте количество дней бана или выберите вариант."},
+					[]form.Option{
+						{
+							ID:    "7",
+							Label: "7 дней",
+							Color: vk.SecondaryColor,
+							Value: 7,
+						},
+						{
+							ID:    "30",
+							Label: "30 дней",
+							Color: vk.SecondaryColor,
+							Value: 30,
+						},
+						{
+							ID:    "permanent",
+							Label: "Навсегда",
+							Color: vk.NegativeColor,
+							Value: 0,
+						},
+					}),
+				ExtrudeMessage: func(message *vk.Message) interface{} {
+					if message == nil {
+						return nil
+					}
+
+					days, err := strconv.Atoi(strings.TrimSpace(message.Text))
+					if err != nil {
+						return -1
+					}
+
+					return days
+				},
+				Check: func(value interface{}) (*check.Result, error) {
+					days, ok := value.(int)
+					if !ok || days < 0 {
+						return check.NewResult("Отправьте количество дней числом."), nil
+					}
+
+					return nil, nil
+				},
+			}
+
+			reason := form.Field{
+				Name: "reason",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Укажите причину бана, пользователь увидит ее."},
+					nil),
+				ExtrudeMessage: extrude.Text,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("ban", profile, days, reason)
+			return NewActionNext(form), err
+
+		case "lift":
+			bans, err := c.Ask.Bans(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			var options []form.Option
+			for i := range bans {
+				options = append(options, form.Option{
+					ID:    strconv.Itoa(bans[i].VkID),
+					Label: strconv.Itoa(bans[i].VkID),
+					Value: &bans[i],
+				})
+			}
+
+			field := form.Field{
+				Name: "ban",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Выберите пользователя, с которого нужно снять бан."},
+					options),
+				ExtrudeMessage: nil,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("lift", field)
+			return NewActionNext(form), err
+		}
+	case "paginator":
+		back := state.paginator.Control(payload.Value)
+
+		if back {
+			return NewActionExit(nil), nil
+		}
+
+		return nil, c.Vk.ChangeKeyboard(user.Id,
+			vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+	}
+
+	return nil, nil
+}
+
+func (state *AdminBans) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	if info == nil {
+		return nil, state.Entry(ctx, user, c)
+	}
+
+	switch info.Payload {
+	case "ban":
+		data, err := dict.ExtractStruct[struct {
+			Profile string
+			Days    int
+			Reason  string
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		vk_id, err := c.Vk.ResolveUser(data.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.Ask.BanUser(ctx, ask.AdminActor(user.Id), vk_id, data.Reason, data.Days)
+		if err != nil {
+			return nil, err
+		}
+
+		ban, err := c.Ask.Ban(ctx, vk_id)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgAdminBanned,
+			ts.MsgAdminBannedData{
+				Ban: *ban,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		notification, err := ts.ParseTemplate(
+			ts.MsgAdminBannedNotify,
+			ts.MsgAdminBannedNotifyData{
+				Ban: *ban,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// notify user
+		c.Notify <- &vk.MessageParams{
+			Id:   vk_id,
+			Text: notification,
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+	case "lift":
+		data, err := dict.ExtractStruct[struct {
+			Ban ask.Ban
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.Ask.LiftBan(ctx, ask.AdminActor(user.Id), data.Ban.VkID)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgAdminBanLifted,
+			ts.MsgAdminBanLiftedData{
+				Ban: data.Ban,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		notification, err := ts.ParseTemplate(
+			ts.MsgAdminBanLiftedNotify,
+			ts.MsgAdminBanLiftedNotifyData{
+				Ban: data.Ban,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// notify user
+		c.Notify <- &vk.MessageParams{
+			Id:   data.Ban.VkID,
+			Text: notification,
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, state.Entry(ctx, user, c)
+}
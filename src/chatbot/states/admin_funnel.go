@@ -0,0 +1,113 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+)
+
+type AdminFunnel struct{}
+
+func (state *AdminFunnel) ID() string {
+	return "admin_funnel"
+}
+
+func (state *AdminFunnel) Entry(ctx context.Context, user *User, c *Controls) error {
+	funnel, err := c.Ask.ReservationFunnel(ctx)
+	if err != nil {
+		return err
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgAdminFunnel,
+		ts.MsgAdminFunnelData{
+			Funnel: *funnel,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	buttons := [][]vk.Button{
+		{
+			{
+				Label: "CSV",
+				Color: vk.PrimaryColor,
+
+				Command: "csv",
+			},
+		},
+		{
+			{
+				Label: "Назад",
+				Color: vk.NegativeColor,
+
+				Command: "back",
+			},
+		},
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), buttons),
+		nil)
+	return err
+}
+
+func (state *AdminFunnel) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	return nil, nil
+}
+
+func (state *AdminFunnel) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "csv":
+		funnel, err := c.Ask.ReservationFunnel(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		attachment, err := state.uploadCSV(user.Id, c, funnel)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, "", "", api.Params{"attachment": attachment})
+		return nil, err
+	case "back":
+		return NewActionExit(nil), nil
+	}
+
+	return nil, nil
+}
+
+func (state *AdminFunnel) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
+}
+
+func (state *AdminFunnel) uploadCSV(user_id int, c *Controls, funnel *ask.Funnel) (string, error) {
+	var content bytes.Buffer
+
+	err := funnel.WriteCSV(&content)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("reservation_funnel_%s.csv", time.Now().Format(time.DateOnly))
+
+	id, err := c.Vk.UploadDocument(user_id, name, &content)
+	if err != nil {
+		return "", err
+	}
+	if id == 0 {
+		return "", errors.New("no doc id")
+	}
+
+	return fmt.Sprintf("%s%d_%d", "doc", user_id, id), nil
+}
@@ -0,0 +1,67 @@
+package states
+
+import (
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+)
+
+const MaxLengthPollDecisions int = 10
+
+// log of how ended polls were decided
+type AdminPollDecisions struct{}
+
+func (state *AdminPollDecisions) ID() string {
+	return "admin_poll_decisions"
+}
+
+func (state *AdminPollDecisions) Entry(ctx context.Context, user *User, c *Controls) error {
+	decisions, err := c.Ask.PollDecisions(ctx, MaxLengthPollDecisions)
+	if err != nil {
+		return err
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgAdminPollDecisions,
+		ts.MsgAdminPollDecisionsData{
+			Decisions: decisions,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	buttons := [][]vk.Button{
+		{
+			{
+				Label: "Назад",
+				Color: vk.NegativeColor,
+
+				Command: "back",
+			},
+		},
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), buttons),
+		nil)
+	return err
+}
+
+func (state *AdminPollDecisions) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	return nil, nil
+}
+
+func (state *AdminPollDecisions) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "back":
+		return NewActionExit(nil), nil
+	}
+
+	return nil, nil
+}
+
+func (state *AdminPollDecisions) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
+}
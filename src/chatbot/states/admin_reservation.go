@@ -5,11 +5,18 @@ import (
 	"ask-bot/src/datatypes/dict"
 	"ask-bot/src/datatypes/form"
 	"ask-bot/src/datatypes/form/check"
+	"ask-bot/src/datatypes/form/extrude"
 	"ask-bot/src/datatypes/paginator"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
+	"database/sql"
+	"fmt"
 	"slices"
 	"strconv"
+	"strings"
+
+	"github.com/SevereCloud/vksdk/v2/api"
 )
 
 type AdminReservation struct {
@@ -20,11 +27,7 @@ func (state *AdminReservation) ID() string {
 	return "admin_reservation"
 }
 
-func (state *AdminReservation) options(num_reservations int, under_consideration bool) (options []form.Option) {
-	if num_reservations == 0 {
-		return nil
-	}
-
+func (state *AdminReservation) options(num_reservations int, under_consideration bool, under_review bool, pending_extensions bool) (options []form.Option) {
 	if under_consideration {
 		options = append(options, form.Option{
 			ID:    "considerate",
@@ -33,18 +36,42 @@ func (state *AdminReservation) options(num_reservations int, under_consideration
 		})
 	}
 
+	if under_review {
+		options = append(options, form.Option{
+			ID:    "review",
+			Label: "Приветствия",
+			Color: vk.PrimaryColor,
+		})
+	}
+
+	if pending_extensions {
+		options = append(options, form.Option{
+			ID:    "extension",
+			Label: "Продления",
+			Color: vk.PrimaryColor,
+		})
+	}
+
 	options = append(options, form.Option{
-		ID:    "delete",
-		Label: "Удалить",
+		ID:    "create",
+		Label: "Создать",
 		Color: vk.SecondaryColor,
 	})
 
+	if num_reservations > 0 {
+		options = append(options, form.Option{
+			ID:    "delete",
+			Label: "Удалить",
+			Color: vk.SecondaryColor,
+		})
+	}
+
 	return
 }
 
 // To-DO: print all reservations
-func (state *AdminReservation) Entry(user *User, c *Controls) error {
-	reservations, err := c.Ask.Reservations()
+func (state *AdminReservation) Entry(ctx context.Context, user *User, c *Controls) error {
+	reservations, err := c.Ask.Reservations(ctx)
 	if err != nil {
 		return err
 	}
@@ -63,6 +90,15 @@ func (state *AdminReservation) Entry(user *User, c *Controls) error {
 		return r.Status == ask.ReservationStatuses.UnderConsideration
 	})
 
+	under_review := slices.ContainsFunc(reservations, func(r ask.Reservation) bool {
+		return r.Status == ask.ReservationStatuses.UnderReview
+	})
+
+	extensions, err := c.Ask.PendingReservationExtensions(ctx)
+	if err != nil {
+		return err
+	}
+
 	config := &paginator.Config[form.Option]{
 		Command: "options",
 
@@ -71,7 +107,7 @@ func (state *AdminReservation) Entry(user *User, c *Controls) error {
 		ToValue: form.OptionToValue,
 	}
 
-	state.paginator = paginator.New(state.options(len(reservations), under_consideration),
+	state.paginator = paginator.New(state.options(len(reservations), under_consideration, under_review, len(extensions) > 0),
 		config.MustBuild())
 
 	_, err = c.Vk.SendMessage(user.Id,
@@ -82,11 +118,11 @@ func (state *AdminReservation) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *AdminReservation) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *AdminReservation) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
 
-func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *AdminReservation) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "options":
 		option, err := state.paginator.Object(payload.Value)
@@ -96,7 +132,7 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 
 		switch option.ID {
 		case "considerate":
-			reservations, err := c.Ask.UnderConsiderationReservations()
+			reservations, err := c.Ask.UnderConsiderationReservations(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -104,7 +140,7 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 			var options []form.Option
 			for _, r := range reservations {
 				options = append(options, form.Option{
-					ID:    strconv.Itoa(r.VkID),
+					ID:    reservationOptionID(r),
 					Label: r.ShownName,
 					Value: r,
 				})
@@ -127,10 +163,16 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 						return nil, false, err
 					}
 
+					archive, err := c.Ask.ArchivedReservations(ctx, r.Reservation.VkID)
+					if err != nil {
+						return nil, false, err
+					}
+
 					message, err := ts.ParseTemplate(
 						ts.MsgAdminReservationConsiderate,
 						ts.MsgAdminReservationConsiderateData{
 							Reservation: r.Reservation,
+							Archive:     archive,
 						},
 					)
 					if err != nil {
@@ -138,7 +180,7 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 					}
 
 					forward, err := vk.ForwardParam(
-						r.Reservation.VkID,
+						r.Reservation.IntroductionPeerID(),
 						[]int{r.Reservation.Introduction})
 					if err != nil {
 						return nil, false, err
@@ -169,11 +211,44 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 				Check:          check.NotEmptyBool,
 			}
 
-			form, err := NewForm("considerate", reservation, decision)
+			// only declined reservation needs reason
+			reason := form.Field{
+				Name: "reason",
+				BuildRequest: func(d dict.Dictionary) (*form.Request, bool, error) {
+					data, err := dict.ExtractStruct[struct {
+						Decision bool
+					}](d)
+					if err != nil {
+						return nil, false, err
+					}
+
+					if data.Decision {
+						return nil, true, nil
+					}
+
+					return &form.Request{
+						Message: &vk.MessageParams{
+							Text: "Напишите причину отказа.",
+						},
+						Options: []form.Option{
+							{
+								ID:    "no_reason",
+								Color: vk.SecondaryColor,
+								Label: "Без причины",
+								Value: "",
+							},
+						},
+					}, false, nil
+				},
+				ExtrudeMessage: extrude.Text,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("considerate", reservation, decision, reason)
 			return NewActionNext(form), err
 
-		case "delete":
-			reservations, err := c.Ask.Reservations()
+		case "review":
+			reservations, err := c.Ask.UnderReviewReservations(ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -181,9 +256,268 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 			var options []form.Option
 			for _, r := range reservations {
 				options = append(options, form.Option{
-					ID:    strconv.Itoa(r.VkID),
+					ID:    reservationOptionID(r),
 					Label: r.ShownName,
-					Value: &r,
+					Value: r,
+				})
+			}
+
+			reservation := form.Field{
+				Name:           "reservation",
+				BuildRequest:   form.AlwaysRequest(&vk.MessageParams{Text: "Выберите приветствие для проверки."}, options),
+				ExtrudeMessage: nil,
+				Check:          check.NotEmpty,
+			}
+
+			// greeting is shown to admin as images in dialog
+			decision := form.Field{
+				Name: "decision",
+				BuildRequest: func(d dict.Dictionary) (*form.Request, bool, error) {
+					r, err := dict.ExtractStruct[struct {
+						Reservation ask.Reservation
+					}](d)
+					if err != nil {
+						return nil, false, err
+					}
+
+					message, err := ts.ParseTemplate(
+						ts.MsgAdminReservationReview,
+						ts.MsgAdminReservationReviewData{
+							Reservation: r.Reservation,
+						},
+					)
+					if err != nil {
+						return nil, false, err
+					}
+
+					attachments, err := uploadGreeting(ctx, c, user.Id, r.Reservation.Greeting)
+					if err != nil {
+						return nil, false, err
+					}
+
+					return &form.Request{
+						Message: &vk.MessageParams{
+							Text: message,
+							Params: api.Params{
+								"attachment": attachments,
+							},
+						},
+						Options: []form.Option{
+							{
+								ID:    "accept",
+								Color: vk.PrimaryColor,
+								Label: "Принять",
+								Value: true,
+							},
+							{
+								ID:    "reject",
+								Color: vk.SecondaryColor,
+								Label: "Отклонить",
+								Value: false,
+							},
+						},
+					}, false, nil
+				},
+				ExtrudeMessage: nil,
+				Check:          check.NotEmptyBool,
+			}
+
+			// only rejected greeting needs comment
+			comment := form.Field{
+				Name: "comment",
+				BuildRequest: func(d dict.Dictionary) (*form.Request, bool, error) {
+					data, err := dict.ExtractStruct[struct {
+						Decision bool
+					}](d)
+					if err != nil {
+						return nil, false, err
+					}
+
+					if data.Decision {
+						return nil, true, nil
+					}
+
+					return &form.Request{
+						Message: &vk.MessageParams{
+							Text: "Напишите комментарий к отказу.",
+						},
+						Options: []form.Option{
+							{
+								ID:    "no_comment",
+								Color: vk.SecondaryColor,
+								Label: "Без комментария",
+								Value: "",
+							},
+						},
+					}, false, nil
+				},
+				ExtrudeMessage: extrude.Text,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("review", reservation, decision, comment)
+			return NewActionNext(form), err
+
+		case "extension":
+			extensions, err := c.Ask.PendingReservationExtensions(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			var options []form.Option
+			for _, e := range extensions {
+				options = append(options, form.Option{
+					ID:    strconv.Itoa(e.Id),
+					Label: fmt.Sprintf("%s +%d", e.Role, e.Days),
+					Value: e,
+				})
+			}
+
+			extension := form.Field{
+				Name:           "extension",
+				BuildRequest:   form.AlwaysRequest(&vk.MessageParams{Text: "Выберите продление для рассмотрения."}, options),
+				ExtrudeMessage: nil,
+				Check:          check.NotEmpty,
+			}
+
+			decision := form.Field{
+				Name: "decision",
+				BuildRequest: func(d dict.Dictionary) (*form.Request, bool, error) {
+					data, err := dict.ExtractStruct[struct {
+						Extension ask.ReservationExtension
+					}](d)
+					if err != nil {
+						return nil, false, err
+					}
+
+					message, err := ts.ParseTemplate(
+						ts.MsgAdminReservationExtension,
+						ts.MsgAdminReservationExtensionData{
+							ReservationExtension: data.Extension,
+						},
+					)
+					if err != nil {
+						return nil, false, err
+					}
+
+					return &form.Request{
+						Message: &vk.MessageParams{
+							Text: message,
+						},
+						Options: []form.Option{
+							{
+								ID:    "approve",
+								Color: vk.PrimaryColor,
+								Label: "Одобрить",
+								Value: true,
+							},
+							{
+								ID:    "decline",
+								Color: vk.SecondaryColor,
+								Label: "Отклонить",
+								Value: false,
+							},
+						},
+					}, false, nil
+				},
+				ExtrudeMessage: nil,
+				Check:          check.NotEmptyBool,
+			}
+
+			form, err := NewForm("extension", extension, decision)
+			return NewActionNext(form), err
+
+		case "create":
+			// profile link is resolved again on creation
+			profile := form.Field{
+				Name: "profile",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Отправьте id пользователя или ссылку на его страницу."},
+					nil),
+				ExtrudeMessage: extrude.Text,
+				Check: func(value interface{}) (*check.Result, error) {
+					profile, ok := value.(string)
+					if !ok || len(profile) == 0 {
+						return check.NewResult("Поле обязательно для заполнения."), nil
+					}
+
+					vk_id, err := c.Vk.ResolveUser(profile)
+					if err != nil {
+						return nil, err
+					}
+					if vk_id == 0 {
+						return check.NewResult("Пользователь не найден."), nil
+					}
+
+					can_reserve, err := c.Ask.CanReserve(ctx, vk_id)
+					if err != nil {
+						return nil, err
+					}
+					if !can_reserve {
+						return check.NewResult("У пользователя уже максимальное количество броней."), nil
+					}
+
+					return nil, nil
+				},
+			}
+
+			role := form.Field{
+				Name: "role",
+				BuildRequest: func(d dict.Dictionary) (*form.Request, bool, error) {
+					roles, err := c.Ask.AvailableRoles(ctx)
+					if err != nil {
+						return nil, false, err
+					}
+
+					var options []form.Option
+					for _, r := range roles {
+						options = append(options, form.Option{
+							ID:    r.Name,
+							Label: r.ShownName,
+							Value: r,
+						})
+					}
+
+					return &form.Request{
+						Message: &vk.MessageParams{Text: "Выберите роль для брони."},
+						Options: options,
+					}, false, nil
+				},
+				ExtrudeMessage: nil,
+				Check:          check.NotEmpty,
+			}
+
+			introduction := form.Field{
+				Name: "introduction",
+				BuildRequest: form.AlwaysRequest(
+					&vk.MessageParams{Text: "Перешлите сообщение пользователя с представлением."},
+					[]form.Option{
+						{
+							ID:    "no_introduction",
+							Color: vk.SecondaryColor,
+							Label: "Без представления",
+							Value: 0,
+						},
+					}),
+				ExtrudeMessage: extrude.ID,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("create", profile, role, introduction)
+			return NewActionNext(form), err
+
+		case "delete":
+			reservations, err := c.Ask.Reservations(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			var options []form.Option
+			for i := range reservations {
+				options = append(options, form.Option{
+					ID:    reservationOptionID(reservations[i]),
+					Label: reservations[i].ShownName,
+					Value: &reservations[i],
 				})
 			}
 
@@ -213,9 +547,9 @@ func (state *AdminReservation) KeyboardEvent(user *User, c *Controls, payload *v
 	return nil, nil
 }
 
-func (state *AdminReservation) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
+func (state *AdminReservation) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
 	if info == nil {
-		return nil, state.Entry(user, c)
+		return nil, state.Entry(ctx, user, c)
 	}
 
 	switch info.Payload {
@@ -223,35 +557,181 @@ func (state *AdminReservation) Back(user *User, c *Controls, info *ExitInfo) (*A
 		data, err := dict.ExtractStruct[struct {
 			Reservation ask.Reservation
 			Decision    bool
+			Reason      string
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		err = considerateReservation(ctx, user, c, ts.MsgAdminReservationConsideratedData(data))
+		if err != nil {
+			return nil, err
+		}
+
+	case "review":
+		data, err := dict.ExtractStruct[struct {
+			Reservation ask.Reservation
+			Decision    bool
+			Comment     string
 		}](info.Values)
 		if err != nil {
 			return nil, err
 		}
 
 		if data.Decision {
-			deadline, err := c.Ask.ConfirmReservation(data.Reservation.VkID)
-			if err != nil {
-				return nil, err
-			}
+			err = c.Ask.AcceptGreeting(ctx, ask.AdminActor(user.Id), data.Reservation.VkID, data.Reservation.Name)
+		} else {
+			err = c.Ask.RejectGreeting(ctx, ask.AdminActor(user.Id),
+				data.Reservation.VkID,
+				data.Reservation.Name,
+				data.Comment)
+		}
+		if err != nil {
+			return nil, err
+		}
 
-			data.Reservation.Deadline.Time = deadline
+		message, err := ts.ParseTemplate(
+			ts.MsgAdminReservationReviewed,
+			ts.MsgAdminReservationReviewedData(data),
+		)
+		if err != nil {
+			return nil, err
+		}
+		notification, err := ts.ParseTemplate(
+			ts.MsgAdminReservationReviewedNotify,
+			ts.MsgAdminReservationReviewedNotifyData(data),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// notify user
+		c.Notify <- &vk.MessageParams{
+			Id:   data.Reservation.VkID,
+			Text: notification,
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+	case "extension":
+		data, err := dict.ExtractStruct[struct {
+			Extension ask.ReservationExtension
+			Decision  bool
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		result := ts.MsgAdminReservationExtendedData{
+			Extension: data.Extension,
+			Decision:  data.Decision,
+		}
+
+		var holders []int
+		if data.Decision {
+			result.Deadline, holders, err = c.Ask.ApproveReservationExtension(ctx, ask.AdminActor(user.Id), data.Extension.Id)
 		} else {
-			err := c.Ask.DeleteReservation(data.Reservation.VkID)
+			err = c.Ask.DeclineReservationExtension(ctx, ask.AdminActor(user.Id), data.Extension.Id)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgAdminReservationExtended,
+			result,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notification, err := ts.ParseTemplate(
+			ts.MsgAdminReservationExtendedNotify,
+			ts.MsgAdminReservationExtendedNotifyData(result),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// notify user
+		c.Notify <- &vk.MessageParams{
+			Id:   data.Extension.VkID,
+			Text: notification,
+		}
+
+		// deadline of the role is shared, so other holders are notified too
+		if len(holders) > 1 {
+			role_notification, err := ts.ParseTemplate(
+				ts.MsgAdminReservationExtendedRoleNotify,
+				ts.MsgAdminReservationExtendedRoleNotifyData(result),
+			)
 			if err != nil {
 				return nil, err
 			}
+
+			for _, vk_id := range holders {
+				if vk_id == data.Extension.VkID {
+					continue
+				}
+
+				c.Notify <- &vk.MessageParams{
+					Id:   vk_id,
+					Text: role_notification,
+				}
+			}
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		if err != nil {
+			return nil, err
+		}
+
+	case "create":
+		data, err := dict.ExtractStruct[struct {
+			Profile      string
+			Role         ask.Role
+			Introduction int
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		vk_id, err := c.Vk.ResolveUser(data.Profile)
+		if err != nil {
+			return nil, err
+		}
+
+		deadline, err := c.Ask.AddConfirmedReservation(ctx, ask.AdminActor(user.Id),
+			vk_id,
+			data.Role.Name,
+			data.Introduction)
+		if err != nil {
+			return nil, err
+		}
+
+		reservation := ask.Reservation{
+			VkID:     vk_id,
+			Deadline: sql.NullTime{Time: deadline, Valid: true},
+			Status:   ask.ReservationStatuses.InProgress,
+			Role:     data.Role,
 		}
 
 		message, err := ts.ParseTemplate(
-			ts.MsgAdminReservationConsiderated,
-			ts.MsgAdminReservationConsideratedData(data),
+			ts.MsgAdminReservationCreated,
+			ts.MsgAdminReservationCreatedData{
+				Reservation: reservation,
+			},
 		)
 		if err != nil {
 			return nil, err
 		}
 		notification, err := ts.ParseTemplate(
-			ts.MsgAdminReservationConsideratedNotify,
-			ts.MsgAdminReservationConsideratedNotifyData(data),
+			ts.MsgAdminReservationCreatedNotify,
+			ts.MsgAdminReservationCreatedNotifyData{
+				Reservation: reservation,
+			},
 		)
 		if err != nil {
 			return nil, err
@@ -259,7 +739,7 @@ func (state *AdminReservation) Back(user *User, c *Controls, info *ExitInfo) (*A
 
 		// notify user
 		c.Notify <- &vk.MessageParams{
-			Id:   data.Reservation.VkID,
+			Id:   vk_id,
 			Text: notification,
 		}
 
@@ -276,7 +756,16 @@ func (state *AdminReservation) Back(user *User, c *Controls, info *ExitInfo) (*A
 			return nil, err
 		}
 
-		err = c.Ask.DeleteReservation(data.Reservation.VkID)
+		offered, err := c.Ask.DeleteReservation(ctx, ask.AdminActor(user.Id),
+			data.Reservation.VkID,
+			data.Reservation.Name,
+			ask.ReservationOutcomes.Deleted,
+			"deleted by admin")
+		if err != nil {
+			return nil, err
+		}
+
+		err = notifyWaitlistOffers(c, offered)
 		if err != nil {
 			return nil, err
 		}
@@ -297,5 +786,84 @@ func (state *AdminReservation) Back(user *User, c *Controls, info *ExitInfo) (*A
 		}
 	}
 
-	return nil, state.Entry(user, c)
+	return nil, state.Entry(ctx, user, c)
+}
+
+// reservation is confirmed or declined, both admin and user are informed
+func considerateReservation(ctx context.Context, user *User, c *Controls, data ts.MsgAdminReservationConsideratedData) error {
+	if data.Decision {
+		deadline, err := c.Ask.ConfirmReservation(ctx, ask.AdminActor(user.Id), data.Reservation.VkID, data.Reservation.Name)
+		if err != nil {
+			return err
+		}
+
+		data.Reservation.Deadline.Time = deadline
+	} else {
+		offered, err := c.Ask.DeclineReservation(ctx, ask.AdminActor(user.Id),
+			data.Reservation.VkID,
+			data.Reservation.Name,
+			data.Reason)
+		if err != nil {
+			return err
+		}
+
+		err = notifyWaitlistOffers(c, offered)
+		if err != nil {
+			return err
+		}
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgAdminReservationConsiderated,
+		data,
+	)
+	if err != nil {
+		return err
+	}
+	notification, err := ts.ParseTemplate(
+		ts.MsgAdminReservationConsideratedNotify,
+		ts.MsgAdminReservationConsideratedNotifyData(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	// notify user
+	c.Notify <- &vk.MessageParams{
+		Id:   data.Reservation.VkID,
+		Text: notification,
+	}
+
+	_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+	return err
+}
+
+// user can have several reservations, so role is a part of id
+func reservationOptionID(r ask.Reservation) string {
+	return fmt.Sprintf("%d_%s", r.VkID, r.Name)
+}
+
+// TO-DO not sure if it is fast way, the same as watcher does for polls
+func uploadGreeting(ctx context.Context, c *Controls, peer_id int, greeting ask.Urls) (string, error) {
+	var attachments []string
+
+	for _, image := range greeting {
+		file, err := c.Ask.OpenImage(ctx, image)
+		if err != nil {
+			return "", err
+		}
+
+		photos, err := c.Vk.UploadPhotoToMessages(peer_id, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+
+		for _, photo := range photos {
+			attachments = append(attachments,
+				fmt.Sprintf("photo%d_%d_%s", photo.OwnerID, photo.ID, photo.AccessKey))
+		}
+	}
+
+	return strings.Join(attachments, ","), nil
 }
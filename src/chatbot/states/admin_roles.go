@@ -5,6 +5,7 @@ import (
 	"ask-bot/src/datatypes/paginator"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
 )
 
 type RolesList struct {
@@ -15,8 +16,8 @@ func (state *RolesList) ID() string {
 	return "roles"
 }
 
-func (state *RolesList) Entry(user *User, c *Controls) error {
-	roles, err := c.Ask.Roles()
+func (state *RolesList) Entry(ctx context.Context, user *User, c *Controls) error {
+	roles, err := c.Ask.Roles(ctx)
 	if err != nil {
 		return err
 	}
@@ -51,8 +52,8 @@ func (state *RolesList) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *RolesList) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
-	roles, err := c.Ask.RolesStartWith(message.Text)
+func (state *RolesList) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	roles, err := c.Ask.RolesStartWith(ctx, message.Text)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +63,7 @@ func (state *RolesList) NewMessage(user *User, c *Controls, message *vk.Message)
 	return nil, c.Vk.ChangeKeyboard(user.Id, vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
 }
 
-func (state *RolesList) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *RolesList) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "roles":
 		role, err := state.paginator.Object(payload.Value)
@@ -95,6 +96,6 @@ func (state *RolesList) KeyboardEvent(user *User, c *Controls, payload *vk.Callb
 	return nil, nil
 }
 
-func (state *RolesList) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *RolesList) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
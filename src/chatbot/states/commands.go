@@ -0,0 +1,278 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"context"
+	"strings"
+	"sync"
+)
+
+// Text commands are alternative to keyboards, they start with slash.
+// Aliases of commands are kept in templates.
+type Command struct {
+	// empty for unknown command
+	ID   ts.TemplateID
+	Args []string
+}
+
+// order matters: if aliases overlap, the first command wins
+var commands = []struct {
+	ID   ts.TemplateID
+	Data interface{}
+}{
+	{ts.CmdHelp, ts.CmdHelpData{}},
+	{ts.CmdReservation, ts.CmdReservationData{}},
+	{ts.CmdPoints, ts.CmdPointsData{}},
+	{ts.CmdDeadline, ts.CmdDeadlineData{}},
+	{ts.CmdCancel, ts.CmdCancelData{}},
+	{ts.CmdAdmin, ts.CmdAdminData{}},
+	{ts.CmdConfirm, ts.CmdConfirmData{}},
+	{ts.CmdDecline, ts.CmdDeclineData{}},
+}
+
+// aliases of commands in the same order, templates are loaded at runtime,
+// so they are parsed on the first command
+var parsed_aliases struct {
+	once sync.Once
+	list [][]string
+	err  error
+}
+
+func commandAliases() ([][]string, error) {
+	parsed_aliases.once.Do(func() {
+		for _, command := range commands {
+			variants, err := ts.ParseVariants(command.ID, command.Data)
+			if err != nil {
+				parsed_aliases.err = err
+				return
+			}
+
+			parsed_aliases.list = append(parsed_aliases.list, variants)
+		}
+	})
+
+	return parsed_aliases.list, parsed_aliases.err
+}
+
+// Free text of form could start with slash too (reason, introduction),
+// so commands are parsed only in states which do not wait for text.
+func ExpectsText(state State) bool {
+	_, ok := state.(*Form)
+	return ok
+}
+
+// nil is returned if text is not a command
+func ParseCommand(text string) (*Command, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return nil, nil
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(text, "/"))
+	if len(fields) == 0 {
+		return &Command{}, nil
+	}
+
+	list, err := commandAliases()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range commands {
+		for _, alias := range list[i] {
+			if strings.EqualFold(alias, fields[0]) {
+				return &Command{
+					ID:   commands[i].ID,
+					Args: fields[1:],
+				}, nil
+			}
+		}
+	}
+
+	return &Command{Args: fields}, nil
+}
+
+// State to start from init state, nil if command is done by itself.
+func (command *Command) State(ctx context.Context, user *User, c *Controls) (State, error) {
+	is_admin, err := c.Ask.IsAdmin(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch command.ID {
+	case ts.CmdHelp:
+		message, err := ts.ParseTemplate(
+			ts.MsgCommands,
+			ts.MsgCommandsData{
+				IsAdmin: is_admin,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		return nil, err
+
+	case ts.CmdReservation:
+		return reservationCommand(ctx, user, c, strings.Join(command.Args, " "))
+
+	case ts.CmdPoints:
+		return &Points{}, nil
+
+	case ts.CmdDeadline:
+		return &Deadline{}, nil
+
+	case ts.CmdCancel:
+		return nil, nil
+	}
+
+	if is_admin {
+		switch command.ID {
+		case ts.CmdAdmin:
+			return &Admin{}, nil
+
+		case ts.CmdConfirm:
+			return nil, considerateCommand(ctx, user, c, command.Args, true)
+
+		case ts.CmdDecline:
+			return nil, considerateCommand(ctx, user, c, command.Args, false)
+		}
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgCommandUnknown,
+		ts.MsgCommandUnknownData{})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+	return nil, err
+}
+
+// new reservation is started with prefix of role if it is possible
+func reservationCommand(ctx context.Context, user *User, c *Controls, prefix string) (State, error) {
+	reservations, err := c.Ask.ReservationsByVkID(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reservations) == 0 {
+		return &ReservationNew{Prefix: prefix}, nil
+	}
+
+	if len(prefix) > 0 {
+		can_reserve, err := c.Ask.CanReserve(ctx, user.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if can_reserve {
+			return &ReservationNew{Prefix: prefix}, nil
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgCommandReservationLimit,
+			ts.MsgCommandReservationLimitData{
+				Prefix: prefix,
+				Limit:  c.Ask.MaxReservations(),
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ReservationManage{}, nil
+}
+
+// args are link to user and optional role if user has several reservations
+func considerateCommand(ctx context.Context, user *User, c *Controls, args []string, decision bool) error {
+	link, role := considerateArgs(args)
+	if len(link) == 0 {
+		message, err := ts.ParseTemplate(
+			ts.MsgCommandUserRequired,
+			ts.MsgCommandUserRequiredData{})
+		if err != nil {
+			return err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		return err
+	}
+
+	vk_id, err := c.Vk.ResolveUser(link)
+	if err != nil {
+		return err
+	}
+	if vk_id == 0 {
+		message, err := ts.ParseTemplate(
+			ts.MsgCommandUserNotFound,
+			ts.MsgCommandUserNotFoundData{Link: link})
+		if err != nil {
+			return err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		return err
+	}
+
+	reservations, err := c.Ask.UnderConsiderationReservations(ctx)
+	if err != nil {
+		return err
+	}
+
+	suitable := suitableReservations(reservations, vk_id, role)
+
+	if len(suitable) != 1 {
+		message, err := ts.ParseTemplate(
+			ts.MsgCommandReservationNotFound,
+			ts.MsgCommandReservationNotFoundData{
+				VkID:         vk_id,
+				Reservations: suitable,
+			})
+		if err != nil {
+			return err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		return err
+	}
+
+	return considerateReservation(ctx, user, c, ts.MsgAdminReservationConsideratedData{
+		Reservation: suitable[0],
+		Decision:    decision,
+	})
+}
+
+// first arg is link to user, the rest is role, empty link if there are no args
+func considerateArgs(args []string) (string, string) {
+	if len(args) == 0 {
+		return "", ""
+	}
+
+	return args[0], strings.Join(args[1:], " ")
+}
+
+// reservations of user, role is matched with name or shown name if it is set
+func suitableReservations(reservations []ask.Reservation, vk_id int, role string) []ask.Reservation {
+	var suitable []ask.Reservation
+	for _, r := range reservations {
+		if r.VkID != vk_id {
+			continue
+		}
+
+		if len(role) > 0 && !strings.EqualFold(r.Name, role) && !strings.EqualFold(r.ShownName, role) {
+			continue
+		}
+
+		suitable = append(suitable, r)
+	}
+
+	return suitable
+}
@@ -0,0 +1,144 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	err := ts.NewFromFile("../../../templates.json")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestCommandAliases(t *testing.T) {
+	for _, want := range commands {
+		aliases, err := ts.ParseVariants(want.ID, want.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, alias := range aliases {
+			command, err := ParseCommand("/" + alias)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if command == nil || command.ID != want.ID {
+				t.Errorf("alias %q is not parsed as %s: %v", alias, want.ID, command)
+			}
+		}
+	}
+}
+
+func TestExpectsText(t *testing.T) {
+	if !ExpectsText(&Form{}) {
+		t.Error("form should take commands as text")
+	}
+	if ExpectsText(&Init{}) {
+		t.Error("init should parse commands")
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		// nil if text is not a command
+		want *Command
+	}{
+		{"not a command", "бронь", nil},
+		{"slash in the middle", "моя /бронь", nil},
+		{"empty command", "/", &Command{}},
+		{"only spaces", "/   ", &Command{}},
+		{"without args", "/бронь", &Command{ID: ts.CmdReservation}},
+		{"with args", "/бронь Гарри Поттер", &Command{ID: ts.CmdReservation, Args: []string{"Гарри", "Поттер"}}},
+		{"case insensitive", "/БРОНЬ", &Command{ID: ts.CmdReservation}},
+		{"surrounding spaces", "  /баллы  ", &Command{ID: ts.CmdPoints}},
+		{"several aliases", "/дедлайны", &Command{ID: ts.CmdDeadline}},
+		{"unknown", "/абв где", &Command{Args: []string{"абв", "где"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			command, err := ParseCommand(test.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (command == nil) != (test.want == nil) {
+				t.Fatalf("got %v, want %v", command, test.want)
+			}
+			if command == nil {
+				return
+			}
+
+			if command.ID != test.want.ID || !slices.Equal(command.Args, test.want.Args) {
+				t.Errorf("got %v, want %v", command, test.want)
+			}
+		})
+	}
+}
+
+func TestConsiderateArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		link string
+		role string
+	}{
+		{"no args", nil, "", ""},
+		{"only link", []string{"@id1"}, "@id1", ""},
+		{"link and role", []string{"vk.com/id1", "Гарри"}, "vk.com/id1", "Гарри"},
+		{"role of several words", []string{"1", "Гарри", "Поттер"}, "1", "Гарри Поттер"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			link, role := considerateArgs(test.args)
+			if link != test.link || role != test.role {
+				t.Errorf("got (%q, %q), want (%q, %q)", link, role, test.link, test.role)
+			}
+		})
+	}
+}
+
+func TestSuitableReservations(t *testing.T) {
+	reservations := []ask.Reservation{
+		{VkID: 1, Role: ask.Role{Name: "harry", ShownName: "Гарри"}},
+		{VkID: 1, Role: ask.Role{Name: "ron", ShownName: "Рон"}},
+		{VkID: 2, Role: ask.Role{Name: "hermione", ShownName: "Гермиона"}},
+	}
+
+	tests := []struct {
+		name  string
+		vk_id int
+		role  string
+		want  []string
+	}{
+		{"all of user", 1, "", []string{"harry", "ron"}},
+		{"by name", 1, "RON", []string{"ron"}},
+		{"by shown name", 1, "гарри", []string{"harry"}},
+		{"role of other user", 1, "Гермиона", nil},
+		{"unknown user", 3, "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var names []string
+			for _, r := range suitableReservations(reservations, test.vk_id, test.role) {
+				names = append(names, r.Name)
+			}
+
+			if !slices.Equal(names, test.want) {
+				t.Errorf("got %v, want %v", names, test.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"ask-bot/src/templates/russian"
 	"ask-bot/src/vk"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -20,8 +21,8 @@ func (state *Deadline) ID() string {
 	return "deadline"
 }
 
-func (state *Deadline) Entry(user *User, c *Controls) error {
-	members, err := c.Ask.MembersByVkID(user.Id)
+func (state *Deadline) Entry(ctx context.Context, user *User, c *Controls) error {
+	members, err := c.Ask.MembersByVkID(ctx, user.Id)
 	if err != nil {
 		return err
 	}
@@ -60,14 +61,14 @@ func (state *Deadline) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *Deadline) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *Deadline) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
 
-func (state *Deadline) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *Deadline) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "history":
-		history, err := c.Ask.DeadlineJournal(user.Id)
+		history, err := c.Ask.DeadlineJournal(ctx, user.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -86,8 +87,8 @@ func (state *Deadline) KeyboardEvent(user *User, c *Controls, payload *vk.Callba
 	return nil, nil
 }
 
-func (state *Deadline) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *Deadline) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
 
 func (state *Deadline) PrepareHistory(user_id int, c *Controls, history []ask.DeadlineEvent) (message string, attachment string, err error) {
@@ -1,6 +1,9 @@
 package states
 
-import "ask-bot/src/vk"
+import (
+	"ask-bot/src/vk"
+	"context"
+)
 
 type FAQ struct{}
 
@@ -8,7 +11,7 @@ func (state *FAQ) ID() string {
 	return "faq"
 }
 
-func (state *FAQ) Entry(user *User, c *Controls) error {
+func (state *FAQ) Entry(ctx context.Context, user *User, c *Controls) error {
 	buttons := [][]vk.Button{{
 		{
 			Label: "Кто ты?",
@@ -36,11 +39,11 @@ func (state *FAQ) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *FAQ) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *FAQ) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
 
-func (state *FAQ) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *FAQ) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "who":
 		_, err := c.Vk.SendMessage(user.Id, "Я подрядчик этого дома.", "", nil)
@@ -55,6 +58,6 @@ func (state *FAQ) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPay
 	return nil, nil
 }
 
-func (state *FAQ) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *FAQ) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
@@ -4,6 +4,7 @@ import (
 	"ask-bot/src/datatypes/form"
 	"ask-bot/src/datatypes/form/check"
 	"ask-bot/src/vk"
+	"context"
 	"errors"
 
 	"github.com/hori-ryota/zaperr"
@@ -31,7 +32,7 @@ func (state *Form) ID() string {
 	return "form"
 }
 
-func (state *Form) Entry(user *User, c *Controls) error {
+func (state *Form) Entry(ctx context.Context, user *User, c *Controls) error {
 	if state.f == nil {
 		err := errors.New("no form is provided")
 		return zaperr.Wrap(err, "")
@@ -40,7 +41,7 @@ func (state *Form) Entry(user *User, c *Controls) error {
 	return state.sendRequest(user, c)
 }
 
-func (state *Form) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *Form) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	end, err := state.set(user, c, message)
 	if err != nil {
 		return nil, err
@@ -53,7 +54,7 @@ func (state *Form) NewMessage(user *User, c *Controls, message *vk.Message) (*Ac
 	return nil, nil
 }
 
-func (state *Form) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *Form) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "form":
 		end, err := state.set(user, c, payload.Value)
@@ -79,8 +80,8 @@ func (state *Form) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPa
 	return nil, nil
 }
 
-func (state *Form) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *Form) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
 
 func (state *Form) sendRequest(user *User, c *Controls) error {
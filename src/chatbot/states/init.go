@@ -5,6 +5,7 @@ import (
 	"ask-bot/src/datatypes/paginator"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
 	"errors"
 
 	"github.com/hori-ryota/zaperr"
@@ -21,16 +22,16 @@ func (state *Init) ID() string {
 	return "init"
 }
 
-func (state *Init) options(user *User, c *Controls) ([]form.Option, error) {
+func (state *Init) options(ctx context.Context, user *User, c *Controls) ([]form.Option, error) {
 	options := []form.Option{}
 
-	reservation, err := c.Ask.ReservationByVkID(user.Id)
+	reservations, err := c.Ask.ReservationsByVkID(ctx, user.Id)
 	if err != nil {
 		return nil, err
 	}
 
 	// same  id because i want to mask the difference between them
-	if reservation == nil {
+	if len(reservations) == 0 {
 		options = append(options, form.Option{
 			ID:    "reservation",
 			Label: "Бронь",
@@ -50,13 +51,18 @@ func (state *Init) options(user *User, c *Controls) ([]form.Option, error) {
 			Label: "Баллы",
 			Value: &Points{},
 		},
+		form.Option{
+			ID:    (&PollsArchive{}).ID(),
+			Label: "Опросы",
+			Value: &PollsArchive{},
+		},
 		form.Option{
 			ID:    (&FAQ{}).ID(),
 			Label: "FAQ",
 			Value: &FAQ{},
 		})
 
-	is_admin, err := c.Ask.IsAdmin(user.Id)
+	is_admin, err := c.Ask.IsAdmin(ctx, user.Id)
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +78,8 @@ func (state *Init) options(user *User, c *Controls) ([]form.Option, error) {
 	return options, nil
 }
 
-func (state *Init) updatePaginator(user *User, c *Controls) error {
-	options, err := state.options(user, c)
+func (state *Init) updatePaginator(ctx context.Context, user *User, c *Controls) error {
+	options, err := state.options(ctx, user, c)
 	if err != nil {
 		return err
 	}
@@ -99,8 +105,8 @@ func (state *Init) updatePaginator(user *User, c *Controls) error {
 	return nil
 }
 
-func (state *Init) Entry(user *User, c *Controls) error {
-	err := state.updatePaginator(user, c)
+func (state *Init) Entry(ctx context.Context, user *User, c *Controls) error {
+	err := state.updatePaginator(ctx, user, c)
 	if err != nil {
 		return err
 	}
@@ -124,13 +130,13 @@ func (state *Init) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *Init) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *Init) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	state.Silent = true
 
-	return nil, state.Entry(user, c)
+	return nil, state.Entry(ctx, user, c)
 }
 
-func (state *Init) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *Init) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "options":
 		option, err := state.paginator.Object(payload.Value)
@@ -152,8 +158,8 @@ func (state *Init) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPa
 	return nil, nil
 }
 
-func (state *Init) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
+func (state *Init) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
 	state.Silent = true
 
-	return nil, state.Entry(user, c)
+	return nil, state.Entry(ctx, user, c)
 }
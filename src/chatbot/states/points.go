@@ -6,6 +6,7 @@ import (
 	"ask-bot/src/templates/russian"
 	"ask-bot/src/vk"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -23,8 +24,8 @@ func (state *Points) ID() string {
 	return "points"
 }
 
-func (state *Points) Entry(user *User, c *Controls) error {
-	points, err := c.Ask.PointsByVkID(user.Id)
+func (state *Points) Entry(ctx context.Context, user *User, c *Controls) error {
+	points, err := c.Ask.PointsByVkID(ctx, user.Id)
 	if err != nil {
 		return err
 	}
@@ -66,18 +67,18 @@ func (state *Points) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *Points) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *Points) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
 
-func (state *Points) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *Points) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "spend":
 		message := `Пока что не на что тратить баллы.`
 		_, err := c.Vk.SendMessage(user.Id, message, "", nil)
 		return nil, err
 	case "history":
-		history, err := c.Ask.HistoryPointsByVkID(user.Id)
+		history, err := c.Ask.HistoryPointsByVkID(ctx, user.Id)
 		if err != nil {
 			return nil, err
 		}
@@ -96,8 +97,8 @@ func (state *Points) KeyboardEvent(user *User, c *Controls, payload *vk.Callback
 	return nil, nil
 }
 
-func (state *Points) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
-	return nil, state.Entry(user, c)
+func (state *Points) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
 }
 
 func (state *Points) PrepareHistory(user_id int, c *Controls, history []ask.Points) (message string, attachment string, err error) {
@@ -0,0 +1,112 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	"ask-bot/src/datatypes/paginator"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+)
+
+const MaxLengthPollsArchive int = 5
+
+// ended polls by role, same for users and admins
+type PollsArchive struct {
+	paginator *paginator.Paginator[ask.Role]
+}
+
+func (state *PollsArchive) ID() string {
+	return "polls_archive"
+}
+
+func (state *PollsArchive) Entry(ctx context.Context, user *User, c *Controls) error {
+	roles, err := c.Ask.PollsArchiveRoles(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	config := &paginator.Config[ask.Role]{
+		Command: "roles",
+
+		ToLabel: func(role ask.Role) string {
+			return role.ShownName
+		},
+		ToValue: func(role ask.Role) string {
+			return role.Name
+		},
+	}
+
+	state.paginator = paginator.New(
+		roles,
+		config.MustBuild())
+
+	message, err := ts.ParseTemplate(
+		ts.MsgPollsArchive,
+		ts.MsgPollsArchiveData{
+			Empty: len(roles) == 0,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), state.paginator.Buttons()),
+		nil)
+	return err
+}
+
+func (state *PollsArchive) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	roles, err := c.Ask.PollsArchiveRoles(ctx, message.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	state.paginator.ChangeObjects(roles)
+
+	return nil, c.Vk.ChangeKeyboard(user.Id, vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+}
+
+func (state *PollsArchive) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "roles":
+		role, err := state.paginator.Object(payload.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		polls, err := c.Ask.PollsArchive(ctx, role.Name, MaxLengthPollsArchive)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgPollsArchiveItem,
+			ts.MsgPollsArchiveItemData{
+				Role:  *role,
+				Polls: polls,
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+		return nil, err
+	case "paginator":
+		back := state.paginator.Control(payload.Value)
+
+		if back {
+			return NewActionExit(nil), nil
+		}
+
+		return nil, c.Vk.ChangeKeyboard(user.Id,
+			vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+	}
+
+	return nil, nil
+}
+
+func (state *PollsArchive) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
+}
@@ -9,15 +9,25 @@ import (
 	"ask-bot/src/datatypes/paginator"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
 	"errors"
+	"slices"
+	"strconv"
 
 	"github.com/hori-ryota/zaperr"
 	"go.uber.org/zap"
 )
 
+// User picks reservation to manage if there are several ones
+// or there is place for new one.
 type ReservationManage struct {
+	reservations *paginator.Paginator[ask.Reservation]
+	can_reserve  bool
+
 	paginator   *paginator.Paginator[form.Option]
-	reservation ask.Reservation
+	reservation *ask.Reservation
+	// reservation is picked from list, back returns to it
+	picked bool
 }
 
 func (state *ReservationManage) ID() string {
@@ -30,6 +40,10 @@ func (state *ReservationManage) options() (options []form.Option) {
 			ID:    "greeting",
 			Label: "Приветствие",
 			Color: vk.PrimaryColor,
+		}, form.Option{
+			ID:    "extension",
+			Label: "Продлить",
+			Color: vk.SecondaryColor,
 		})
 	}
 
@@ -44,28 +58,104 @@ func (state *ReservationManage) options() (options []form.Option) {
 	return
 }
 
-func (state *ReservationManage) Entry(user *User, c *Controls) error {
-	reservation, err := c.Ask.ReservationByVkID(user.Id)
+func (state *ReservationManage) Entry(ctx context.Context, user *User, c *Controls) error {
+	reservations, err := c.Ask.ReservationsByVkID(ctx, user.Id)
 	if err != nil {
 		return err
 	}
 
-	if reservation == nil {
+	if len(reservations) == 0 {
 		err = errors.New("there is no reservations")
 		return zaperr.Wrap(err, "",
 			zap.Int("user", user.Id))
 	}
 
-	state.reservation = *reservation
+	state.can_reserve, err = c.Ask.CanReserve(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	// picked reservation is updated, it could be deleted meanwhile
+	if state.reservation != nil {
+		index := slices.IndexFunc(reservations, func(r ask.Reservation) bool {
+			return r.Name == state.reservation.Name
+		})
+		if index != -1 {
+			state.reservation = &reservations[index]
+			return state.show(user, c)
+		}
+
+		state.reservation = nil
+	}
+
+	// nothing to pick
+	if len(reservations) == 1 && !state.can_reserve {
+		state.reservation = &reservations[0]
+		state.picked = false
+		return state.show(user, c)
+	}
+
+	return state.list(user, c, reservations)
+}
 
+func (state *ReservationManage) listButtons() [][]vk.Button {
+	if !state.can_reserve {
+		return state.reservations.Buttons()
+	}
+
+	return state.reservations.Buttons(vk.Button{
+		Label: "Новая бронь",
+		Color: vk.PrimaryColor,
+
+		Command: "new",
+	})
+}
+
+func (state *ReservationManage) list(user *User, c *Controls, reservations []ask.Reservation) error {
+	message, err := ts.ParseTemplate(
+		ts.MsgReservationList,
+		ts.MsgReservationListData{
+			Reservations: reservations,
+			CanReserve:   state.can_reserve,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	config := &paginator.Config[ask.Reservation]{
+		Command: "reservations",
+
+		ToLabel: func(reservation ask.Reservation) string {
+			return reservation.ShownName
+		},
+		ToValue: func(reservation ask.Reservation) string {
+			return reservation.Name
+		},
+	}
+
+	state.reservations = paginator.New(
+		reservations,
+		config.MustBuild())
+
+	_, err = c.Vk.SendMessage(
+		user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), state.listButtons()),
+		nil)
+	return err
+}
+
+func (state *ReservationManage) show(user *User, c *Controls) error {
 	var message string
+	var err error
 
 	switch state.reservation.Status {
 	case ask.ReservationStatuses.UnderConsideration:
 		message, err = ts.ParseTemplate(
 			ts.MsgReservationUnderConsideration,
 			ts.MsgReservationUnderConsiderationData{
-				Reservation: state.reservation,
+				Reservation: *state.reservation,
 			},
 		)
 
@@ -73,7 +163,15 @@ func (state *ReservationManage) Entry(user *User, c *Controls) error {
 		message, err = ts.ParseTemplate(
 			ts.MsgReservationInProgress,
 			ts.MsgReservationInProgressData{
-				Reservation: state.reservation,
+				Reservation: *state.reservation,
+			},
+		)
+
+	case ask.ReservationStatuses.UnderReview:
+		message, err = ts.ParseTemplate(
+			ts.MsgReservationUnderReview,
+			ts.MsgReservationUnderReviewData{
+				Reservation: *state.reservation,
 			},
 		)
 
@@ -82,7 +180,7 @@ func (state *ReservationManage) Entry(user *User, c *Controls) error {
 		message, err = ts.ParseTemplate(
 			ts.MsgReservationDone,
 			ts.MsgReservationDoneData{
-				Reservation: state.reservation,
+				Reservation: *state.reservation,
 			},
 		)
 
@@ -90,8 +188,8 @@ func (state *ReservationManage) Entry(user *User, c *Controls) error {
 		message, err = ts.ParseTemplate(
 			ts.MsgReservationPoll,
 			ts.MsgReservationPollData{
-				Reservation: state.reservation,
-				Link:        c.Vk.PostLink(int(reservation.Poll.Int32)),
+				Reservation: *state.reservation,
+				Link:        c.Vk.PostLink(int(state.reservation.Poll.Int32)),
 			},
 		)
 	}
@@ -120,12 +218,25 @@ func (state *ReservationManage) Entry(user *User, c *Controls) error {
 	return err
 }
 
-func (state *ReservationManage) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
+func (state *ReservationManage) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
 	return nil, nil
 }
 
-func (state *ReservationManage) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *ReservationManage) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
+	case "reservations":
+		reservation, err := state.reservations.Object(payload.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		state.reservation = reservation
+		state.picked = true
+		return nil, state.show(user, c)
+
+	case "new":
+		return NewActionNext(&ReservationNew{}), nil
+
 	case "options":
 		option, err := state.paginator.Object(payload.Value)
 		if err != nil {
@@ -151,11 +262,73 @@ func (state *ReservationManage) KeyboardEvent(user *User, c *Controls, payload *
 			form, err := NewForm("greeting", greeting)
 			return NewActionNext(form), err
 
+		case "extension":
+			allowance, err := c.Ask.ReservationExtensionAllowance(ctx, state.reservation.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			if !allowance.Allowed() {
+				message, err := ts.ParseTemplate(
+					ts.MsgReservationExtensionUnavailable,
+					ts.MsgReservationExtensionUnavailableData{
+						ExtensionAllowance: allowance,
+					})
+				if err != nil {
+					return nil, err
+				}
+
+				_, err = c.Vk.SendMessage(user.Id, message, "", nil)
+				return nil, err
+			}
+
+			days_msg, err := ts.ParseTemplate(
+				ts.MsgReservationExtension,
+				ts.MsgReservationExtensionData{
+					ExtensionAllowance: allowance,
+				})
+			if err != nil {
+				return nil, err
+			}
+
+			reason_msg, err := ts.ParseTemplate(
+				ts.MsgReservationExtensionReason,
+				ts.MsgReservationExtensionReasonData{})
+			if err != nil {
+				return nil, err
+			}
+
+			var options []form.Option
+			for i := 1; i <= allowance.Days; i++ {
+				options = append(options, form.Option{
+					ID:    strconv.Itoa(i),
+					Label: strconv.Itoa(i),
+					Value: i,
+				})
+			}
+
+			days := form.Field{
+				Name:           "days",
+				BuildRequest:   form.AlwaysRequest(&vk.MessageParams{Text: days_msg}, options),
+				ExtrudeMessage: nil,
+				Check:          check.NotEmptyPositiveInt,
+			}
+
+			reason := form.Field{
+				Name:           "reason",
+				BuildRequest:   form.AlwaysRequest(&vk.MessageParams{Text: reason_msg}, nil),
+				ExtrudeMessage: extrude.Text,
+				Check:          check.NotEmpty,
+			}
+
+			form, err := NewForm("extension", days, reason)
+			return NewActionNext(form), err
+
 		case "cancel":
 			message, err := ts.ParseTemplate(
 				ts.MsgReservationCancel,
 				ts.MsgReservationCancelData{
-					Reservation: state.reservation,
+					Reservation: *state.reservation,
 				})
 			if err != nil {
 				return nil, err
@@ -172,9 +345,26 @@ func (state *ReservationManage) KeyboardEvent(user *User, c *Controls, payload *
 			return NewActionNext(form), err
 		}
 	case "paginator":
+		// list of reservations
+		if state.reservation == nil {
+			back := state.reservations.Control(payload.Value)
+
+			if back {
+				return NewActionExit(nil), nil
+			}
+
+			return nil, c.Vk.ChangeKeyboard(user.Id,
+				vk.CreateKeyboard(state.ID(), state.listButtons()))
+		}
+
 		back := state.paginator.Control(payload.Value)
 
 		if back {
+			if state.picked {
+				state.reservation = nil
+				return nil, state.Entry(ctx, user, c)
+			}
+
 			return NewActionExit(nil), nil
 		}
 
@@ -186,9 +376,9 @@ func (state *ReservationManage) KeyboardEvent(user *User, c *Controls, payload *
 }
 
 // TO-DO resend greeting maybe?
-func (state *ReservationManage) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
+func (state *ReservationManage) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
 	if info == nil {
-		return nil, state.Entry(user, c)
+		return nil, state.Entry(ctx, user, c)
 	}
 
 	switch info.Payload {
@@ -200,7 +390,43 @@ func (state *ReservationManage) Back(user *User, c *Controls, info *ExitInfo) (*
 			return nil, err
 		}
 
-		err = c.Ask.CompleteReservation(state.reservation.VkID, greeting.Greeting)
+		err = c.Ask.CompleteReservation(ctx, ask.UserActor(user.Id),
+			state.reservation.VkID,
+			state.reservation.Name,
+			greeting.Greeting)
+		if err != nil {
+			return nil, err
+		}
+
+	case "extension":
+		data, err := dict.ExtractStruct[struct {
+			Days   int
+			Reason string
+		}](info.Values)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.Ask.RequestReservationExtension(ctx, ask.UserActor(user.Id),
+			state.reservation.VkID,
+			state.reservation.Name,
+			data.Days,
+			data.Reason)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := ts.ParseTemplate(
+			ts.MsgReservationExtensionSent,
+			ts.MsgReservationExtensionSentData{
+				Days: data.Days,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id, message, "", nil)
 		if err != nil {
 			return nil, err
 		}
@@ -214,10 +440,19 @@ func (state *ReservationManage) Back(user *User, c *Controls, info *ExitInfo) (*
 		}
 
 		if !data.Confirmation {
-			return nil, state.Entry(user, c)
+			return nil, state.Entry(ctx, user, c)
+		}
+
+		offered, err := c.Ask.DeleteReservation(ctx, ask.UserActor(user.Id),
+			state.reservation.VkID,
+			state.reservation.Name,
+			ask.ReservationOutcomes.Cancelled,
+			"cancelled by user")
+		if err != nil {
+			return nil, err
 		}
 
-		err = c.Ask.DeleteReservation(state.reservation.VkID)
+		err = notifyWaitlistOffers(c, offered)
 		if err != nil {
 			return nil, err
 		}
@@ -234,5 +469,5 @@ func (state *ReservationManage) Back(user *User, c *Controls, info *ExitInfo) (*
 		return NewActionExit(nil), err
 	}
 
-	return nil, state.Entry(user, c)
+	return nil, state.Entry(ctx, user, c)
 }
@@ -9,20 +9,95 @@ import (
 	"ask-bot/src/datatypes/paginator"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
+	"slices"
 )
 
 type ReservationNew struct {
+	// roles are filtered by prefix at start, e.g. from text command
+	Prefix string
+
 	paginator *paginator.Paginator[ask.Role]
 
+	// roles offered to user from waitlist
+	offered []ask.Role
+	// roles already reserved by user are not shown
+	reserved []string
+
 	role *ask.Role
+
+	// banned user only can go back
+	banned bool
 }
 
 func (state *ReservationNew) ID() string {
 	return "reservation"
 }
 
-func (state *ReservationNew) Entry(user *User, c *Controls) error {
-	roles, err := c.Ask.AvailableRoles()
+// offered roles are hidden from available ones, so they are always shown first
+func (state *ReservationNew) updateOffered(ctx context.Context, user *User, c *Controls) error {
+	offers, err := c.Ask.WaitlistOffers(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	state.offered = nil
+	for _, offer := range offers {
+		state.offered = append(state.offered, offer.Role)
+	}
+
+	reservations, err := c.Ask.ReservationsByVkID(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	state.reserved = nil
+	for _, reservation := range reservations {
+		state.reserved = append(state.reserved, reservation.Name)
+	}
+
+	return nil
+}
+
+func (state *ReservationNew) roles(available []ask.Role) []ask.Role {
+	roles := append(slices.Clone(state.offered), available...)
+
+	return slices.DeleteFunc(roles, func(role ask.Role) bool {
+		return slices.Contains(state.reserved, role.Name)
+	})
+}
+
+func (state *ReservationNew) buttons() [][]vk.Button {
+	return state.paginator.Buttons(vk.Button{
+		Label: "Очередь",
+		Color: vk.SecondaryColor,
+
+		Command: "waitlist",
+	})
+}
+
+func (state *ReservationNew) Entry(ctx context.Context, user *User, c *Controls) error {
+	ban, err := c.Ask.Ban(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	state.banned = ban != nil
+	if state.banned {
+		return state.showBan(user, c, ban)
+	}
+
+	err = state.updateOffered(ctx, user, c)
+	if err != nil {
+		return err
+	}
+
+	var roles []ask.Role
+	if len(state.Prefix) > 0 {
+		roles, err = c.Ask.AvailableRolesStartWith(ctx, state.Prefix)
+	} else {
+		roles, err = c.Ask.AvailableRoles(ctx)
+	}
 	if err != nil {
 		return err
 	}
@@ -33,13 +108,19 @@ func (state *ReservationNew) Entry(user *User, c *Controls) error {
 		ToLabel: func(role ask.Role) string {
 			return role.ShownName
 		},
+		ToColor: func(role ask.Role) string {
+			if slices.ContainsFunc(state.offered, func(offered ask.Role) bool { return offered.Name == role.Name }) {
+				return vk.PrimaryColor
+			}
+			return vk.SecondaryColor
+		},
 		ToValue: func(role ask.Role) string {
 			return role.Name
 		},
 	}
 
 	state.paginator = paginator.New(
-		roles,
+		state.roles(roles),
 		config.MustBuild())
 
 	message, err := ts.ParseTemplate(
@@ -52,23 +133,56 @@ func (state *ReservationNew) Entry(user *User, c *Controls) error {
 
 	_, err = c.Vk.SendMessage(user.Id,
 		message,
-		vk.CreateKeyboard(state.ID(), state.paginator.Buttons()),
+		vk.CreateKeyboard(state.ID(), state.buttons()),
+		nil)
+	return err
+}
+
+func (state *ReservationNew) showBan(user *User, c *Controls, ban *ask.Ban) error {
+	message, err := ts.ParseTemplate(
+		ts.MsgReservationBanned,
+		ts.MsgReservationBannedData{
+			Ban: *ban,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	buttons := [][]vk.Button{
+		{
+			{
+				Label: "Назад",
+				Color: vk.NegativeColor,
+
+				Command: "back",
+			},
+		},
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), buttons),
 		nil)
 	return err
 }
 
-func (state *ReservationNew) NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error) {
-	roles, err := c.Ask.AvailableRolesStartWith(message.Text)
+func (state *ReservationNew) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	if state.banned {
+		return nil, nil
+	}
+
+	roles, err := c.Ask.AvailableRolesStartWith(ctx, message.Text)
 	if err != nil {
 		return nil, err
 	}
 
-	state.paginator.ChangeObjects(roles)
+	state.paginator.ChangeObjects(state.roles(roles))
 
-	return nil, c.Vk.ChangeKeyboard(user.Id, vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+	return nil, c.Vk.ChangeKeyboard(user.Id, vk.CreateKeyboard(state.ID(), state.buttons()))
 }
 
-func (state *ReservationNew) KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+func (state *ReservationNew) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
 	switch payload.Command {
 	case "roles":
 		role, err := state.paginator.Object(payload.Value)
@@ -128,6 +242,12 @@ func (state *ReservationNew) KeyboardEvent(user *User, c *Controls, payload *vk.
 		form, err := NewForm("introduction", confirm, introduction)
 		return NewActionNext(form), err
 
+	case "waitlist":
+		return NewActionNext(&ReservationWaitlist{}), nil
+
+	case "back":
+		return NewActionExit(nil), nil
+
 	case "paginator":
 		back := state.paginator.Control(payload.Value)
 
@@ -136,15 +256,15 @@ func (state *ReservationNew) KeyboardEvent(user *User, c *Controls, payload *vk.
 		}
 
 		return nil, c.Vk.ChangeKeyboard(user.Id,
-			vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+			vk.CreateKeyboard(state.ID(), state.buttons()))
 	}
 
 	return nil, nil
 }
 
-func (state *ReservationNew) Back(user *User, c *Controls, info *ExitInfo) (*Action, error) {
+func (state *ReservationNew) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
 	if info == nil {
-		return nil, state.Entry(user, c)
+		return nil, state.Entry(ctx, user, c)
 	}
 
 	switch info.Payload {
@@ -158,10 +278,10 @@ func (state *ReservationNew) Back(user *User, c *Controls, info *ExitInfo) (*Act
 		}
 
 		if !data.Confirmation {
-			return nil, state.Entry(user, c)
+			return nil, state.Entry(ctx, user, c)
 		}
 
-		err = c.Ask.AddReservation(user.Id, state.role.Name, data.Introduction)
+		err = c.Ask.AddReservation(ctx, ask.UserActor(user.Id), user.Id, state.role.Name, data.Introduction)
 		if err != nil {
 			return nil, err
 		}
@@ -184,5 +304,5 @@ func (state *ReservationNew) Back(user *User, c *Controls, info *ExitInfo) (*Act
 		return NewActionExit(nil), err
 	}
 
-	return nil, state.Entry(user, c)
+	return nil, state.Entry(ctx, user, c)
 }
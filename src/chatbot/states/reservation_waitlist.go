@@ -0,0 +1,188 @@
+package states
+
+import (
+	"ask-bot/src/ask"
+	"ask-bot/src/datatypes/paginator"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+	"slices"
+)
+
+type ReservationWaitlist struct {
+	paginator *paginator.Paginator[ask.Role]
+
+	entries []ask.WaitlistEntry
+}
+
+func (state *ReservationWaitlist) ID() string {
+	return "reservation_waitlist"
+}
+
+func (state *ReservationWaitlist) waiting(role ask.Role) bool {
+	return slices.ContainsFunc(state.entries, func(entry ask.WaitlistEntry) bool {
+		return entry.Name == role.Name
+	})
+}
+
+// user's entries are kept even if role is not waited for anymore
+func (state *ReservationWaitlist) update(ctx context.Context, user *User, c *Controls) error {
+	entries, err := c.Ask.WaitlistByVkID(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	state.entries = entries
+
+	roles, err := c.Ask.WaitlistRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range state.entries {
+		if !slices.ContainsFunc(roles, func(role ask.Role) bool { return role.Name == entry.Name }) {
+			roles = append(roles, entry.Role)
+		}
+	}
+
+	if state.paginator == nil {
+		config := &paginator.Config[ask.Role]{
+			Command: "roles",
+
+			ToLabel: func(role ask.Role) string {
+				return role.ShownName
+			},
+			ToColor: func(role ask.Role) string {
+				if state.waiting(role) {
+					return vk.PrimaryColor
+				}
+				return vk.SecondaryColor
+			},
+			ToValue: func(role ask.Role) string {
+				return role.Name
+			},
+		}
+
+		state.paginator = paginator.New(
+			roles,
+			config.MustBuild())
+		return nil
+	}
+
+	state.paginator.ChangeObjects(roles)
+	return nil
+}
+
+func (state *ReservationWaitlist) Entry(ctx context.Context, user *User, c *Controls) error {
+	err := state.update(ctx, user, c)
+	if err != nil {
+		return err
+	}
+
+	message, err := ts.ParseTemplate(
+		ts.MsgReservationWaitlist,
+		ts.MsgReservationWaitlistData{
+			Entries: state.entries,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Vk.SendMessage(user.Id,
+		message,
+		vk.CreateKeyboard(state.ID(), state.paginator.Buttons()),
+		nil)
+	return err
+}
+
+func (state *ReservationWaitlist) NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error) {
+	return nil, nil
+}
+
+// chosen role is joined or left
+func (state *ReservationWaitlist) KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error) {
+	switch payload.Command {
+	case "roles":
+		role, err := state.paginator.Object(payload.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		var message string
+
+		if state.waiting(*role) {
+			err = c.Ask.LeaveWaitlist(ctx, ask.UserActor(user.Id), user.Id, role.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			message, err = ts.ParseTemplate(
+				ts.MsgReservationWaitlistLeft,
+				ts.MsgReservationWaitlistLeftData{
+					Role: *role,
+				})
+		} else {
+			err = c.Ask.JoinWaitlist(ctx, ask.UserActor(user.Id), user.Id, role.Name)
+			if err != nil {
+				return nil, err
+			}
+
+			message, err = ts.ParseTemplate(
+				ts.MsgReservationWaitlistJoined,
+				ts.MsgReservationWaitlistJoinedData{
+					Role: *role,
+				})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		err = state.update(ctx, user, c)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = c.Vk.SendMessage(user.Id,
+			message,
+			vk.CreateKeyboard(state.ID(), state.paginator.Buttons()),
+			nil)
+		return nil, err
+
+	case "paginator":
+		back := state.paginator.Control(payload.Value)
+
+		if back {
+			return NewActionExit(nil), nil
+		}
+
+		return nil, c.Vk.ChangeKeyboard(user.Id,
+			vk.CreateKeyboard(state.ID(), state.paginator.Buttons()))
+	}
+
+	return nil, nil
+}
+
+func (state *ReservationWaitlist) Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error) {
+	return nil, state.Entry(ctx, user, c)
+}
+
+// roles freed by user or admin are offered to waitlist at once
+func notifyWaitlistOffers(c *Controls, offered []ask.WaitlistEntry) error {
+	for i := range offered {
+		message, err := ts.ParseTemplate(
+			ts.MsgWaitlistOffer,
+			ts.MsgWaitlistOfferData{
+				WaitlistEntry: offered[i],
+			})
+		if err != nil {
+			return err
+		}
+
+		c.Notify <- &vk.MessageParams{
+			Id:   offered[i].VkID,
+			Text: message,
+		}
+	}
+
+	return nil
+}
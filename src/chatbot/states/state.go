@@ -6,15 +6,16 @@ import (
 	"ask-bot/src/vk"
 	"ask-bot/src/watcher/events"
 	"ask-bot/src/watcher/postponed"
+	"context"
 )
 
 type State interface {
 	ID() string
 
-	Entry(user *User, c *Controls) error
-	NewMessage(user *User, c *Controls, message *vk.Message) (*Action, error)
-	KeyboardEvent(user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error)
-	Back(user *User, c *Controls, info *ExitInfo) (*Action, error)
+	Entry(ctx context.Context, user *User, c *Controls) error
+	NewMessage(ctx context.Context, user *User, c *Controls, message *vk.Message) (*Action, error)
+	KeyboardEvent(ctx context.Context, user *User, c *Controls, payload *vk.CallbackPayload) (*Action, error)
+	Back(ctx context.Context, user *User, c *Controls, info *ExitInfo) (*Action, error)
 }
 
 type User struct {
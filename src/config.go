@@ -1,10 +1,13 @@
 package main
 
 import (
+	"ask-bot/src/ask/db"
 	"encoding/json"
 	"errors"
+	"flag"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -16,12 +19,46 @@ type Config struct {
 	SecretGroupToken string        `json:"SECRET_GROUP_TOKEN"`
 	SecretAdminToken string        `json:"SECRET_ADMIN_TOKEN"`
 	DB               string        `json:"DB"`
+	DBBusyTimeout    time.Duration `json:"DB_BUSY_TIMEOUT"`
 	AllowDeletion    bool          `json:"ALLOW_DELETION"`
 	Schema           string        `json:"SCHEMA"`
 	Templates        string        `json:"TEMPLATES"`
 	Timeout          time.Duration `json:"TIMEOUT"`
 	LogDir           string        `json:"LOG_DIR"`
 	UpdatePostponed  time.Duration `json:"UPDATE_POSTPONED"`
+	BackupDir        string        `json:"BACKUP_DIR"`
+	BackupKeep       int           `json:"BACKUP_KEEP"`
+	BackupMaxAge     time.Duration `json:"BACKUP_MAX_AGE"`
+	BackupInterval   time.Duration `json:"BACKUP_INTERVAL"`
+	ImagesDir        string        `json:"IMAGES_DIR"`
+}
+
+type Flags struct {
+	Plan    bool
+	Check   bool
+	Restore string
+
+	// table=filename, format is chosen by extension
+	Import string
+	Export string
+}
+
+func FlagsFromArgs() *Flags {
+	plan := flag.Bool("plan", false, "print database migration plan and exit")
+	check := flag.Bool("check", false, "check ask queries against migrated copy of database and exit")
+	restore := flag.String("restore", "", "replace database with snapshot and exit")
+	import_table := flag.String("import", "", "import table from csv or json file as table=filename and exit")
+	export_table := flag.String("export", "", "export table to csv or json file as table=filename and exit")
+
+	flag.Parse()
+
+	return &Flags{
+		Plan:    *plan,
+		Check:   *check,
+		Restore: *restore,
+		Import:  *import_table,
+		Export:  *export_table,
+	}
 }
 
 func ConfigFromEnv() *Config {
@@ -44,18 +81,48 @@ func ConfigFromEnv() *Config {
 			"error", err,
 			"update", os.Getenv("UPDATE_POSTPONED"))
 	}
+	busy_timeout, err := time.ParseDuration(os.Getenv("DB_BUSY_TIMEOUT"))
+	if err != nil {
+		zap.S().Warnw("failed to parse db busy timeout",
+			"error", err,
+			"busy timeout", os.Getenv("DB_BUSY_TIMEOUT"))
+	}
+	backup_keep, err := strconv.Atoi(os.Getenv("BACKUP_KEEP"))
+	if err != nil {
+		zap.S().Warnw("failed to parse backup keep",
+			"error", err,
+			"keep", os.Getenv("BACKUP_KEEP"))
+	}
+	backup_max_age, err := time.ParseDuration(os.Getenv("BACKUP_MAX_AGE"))
+	if err != nil {
+		zap.S().Warnw("failed to parse backup max age",
+			"error", err,
+			"max age", os.Getenv("BACKUP_MAX_AGE"))
+	}
+	backup_interval, err := time.ParseDuration(os.Getenv("BACKUP_INTERVAL"))
+	if err != nil {
+		zap.S().Warnw("failed to parse backup interval",
+			"error", err,
+			"interval", os.Getenv("BACKUP_INTERVAL"))
+	}
 
 	return &Config{
 		GroupID:          group_id,
 		SecretGroupToken: os.Getenv("SECRET_GROUP_TOKEN"),
 		SecretAdminToken: os.Getenv("SECRET_ADMIN_TOKEN"),
 		DB:               os.Getenv("DB"),
+		DBBusyTimeout:    busy_timeout,
 		AllowDeletion:    allow_deletion,
 		Templates:        os.Getenv("TEMPLATES"),
 		Schema:           os.Getenv("SCHEMA"),
 		LogDir:           os.Getenv("LOG_DIR"),
 		Timeout:          timeout,
 		UpdatePostponed:  update,
+		BackupDir:        os.Getenv("BACKUP_DIR"),
+		BackupKeep:       backup_keep,
+		BackupMaxAge:     backup_max_age,
+		BackupInterval:   backup_interval,
+		ImagesDir:        os.Getenv("IMAGES_DIR"),
 	}
 }
 
@@ -80,6 +147,14 @@ func ConfigFromFile(name string) (*Config, error) {
 	return c, nil
 }
 
+func (c *Config) Backup() *db.BackupConfig {
+	return &db.BackupConfig{
+		Dir:    c.BackupDir,
+		Keep:   c.BackupKeep,
+		MaxAge: c.BackupMaxAge,
+	}
+}
+
 func (c *Config) Validate() error {
 	if c.GroupID == 0 {
 		return errors.New("group id is not provided")
@@ -93,6 +168,9 @@ func (c *Config) Validate() error {
 	if len(c.DB) == 0 {
 		return errors.New("database url is not provided")
 	}
+	if c.DBBusyTimeout == 0 {
+		c.DBBusyTimeout = 5 * time.Second
+	}
 	// no need to check allow deletion, default is false
 	if len(c.Templates) == 0 {
 		return errors.New("templates file is not provided")
@@ -111,5 +189,22 @@ func (c *Config) Validate() error {
 		c.UpdatePostponed = 1 * time.Minute
 	}
 
+	// snapshots are kept near database by default
+	if len(c.BackupDir) == 0 {
+		c.BackupDir = filepath.Join(filepath.Dir(c.DB), "backups")
+	}
+	// zero max age means snapshots are pruned only by count
+	if c.BackupKeep == 0 {
+		c.BackupKeep = 10
+	}
+	if c.BackupInterval == 0 {
+		c.BackupInterval = 24 * time.Hour
+	}
+
+	// greeting images are kept near database by default
+	if len(c.ImagesDir) == 0 {
+		c.ImagesDir = filepath.Join(filepath.Dir(c.DB), "images")
+	}
+
 	return nil
 }
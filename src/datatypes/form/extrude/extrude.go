@@ -13,6 +13,15 @@ func ID(message *vk.Message) interface{} {
 	return message.ID
 }
 
+// string
+func Text(message *vk.Message) interface{} {
+	if message == nil {
+		return nil
+	}
+
+	return message.Text
+}
+
 // string
 func Attachments(message *vk.Message) interface{} {
 	if message == nil {
@@ -4,6 +4,7 @@ import (
 	"ask-bot/src/ask"
 	"ask-bot/src/datatypes/schedule"
 	"ask-bot/src/vk"
+	"fmt"
 	"regexp"
 	"slices"
 	"strings"
@@ -40,7 +41,7 @@ func ParseKinds(mask Kind) []Kind {
 	index := 0b1000000
 
 	for index > 0 {
-		if int(mask)&index == 1 {
+		if int(mask)&index != 0 {
 			kinds = append(kinds, Kind(index))
 		}
 
@@ -63,6 +64,9 @@ type Post struct {
 	ID   int
 	Date time.Time
 
+	// id of attached vk poll, zero if there is no poll
+	PollID int
+
 	//Poll *Poll
 }
 
@@ -72,6 +76,12 @@ func Parse(vk_post *object.WallWallpost, dictionary []ask.Role, organization *as
 		Date: time.Unix(int64(vk_post.Date), 0),
 	}
 
+	for _, attachment := range vk_post.Attachments {
+		if attachment.Type == object.AttachmentTypePoll {
+			post.PollID = attachment.Poll.ID
+		}
+	}
+
 	post.complete(vk_post.Text, dictionary, organization)
 
 	return post
@@ -88,6 +98,14 @@ func ParseFromParams(id int, params vk.PostParams, dictionary []ask.Role, organi
 		//Poll: nil,
 	}
 
+	// attachment is in format poll<owner>_<id>
+	for _, attachment := range params.Attachments {
+		var owner, id int
+		if n, _ := fmt.Sscanf(attachment, "poll%d_%d", &owner, &id); n == 2 {
+			post.PollID = id
+		}
+	}
+
 	// find kind & roles
 	post.complete(params.Text, dictionary, organization)
 
@@ -0,0 +1,34 @@
+package posts
+
+import (
+	"ask-bot/src/ask"
+	"ask-bot/src/vk"
+	"slices"
+	"testing"
+)
+
+func TestParseKinds(t *testing.T) {
+	kinds := ParseKinds(Kinds.Poll | Kinds.Invalid)
+	if len(kinds) != 2 || !slices.Contains(kinds, Kinds.Poll) || !slices.Contains(kinds, Kinds.Invalid) {
+		t.Errorf("wrong kinds of mask: %v", kinds)
+	}
+
+	kinds = ParseKinds(Kinds.Unknown)
+	if len(kinds) != 1 || kinds[0] != Kinds.Unknown {
+		t.Errorf("wrong kinds of single kind: %v", kinds)
+	}
+}
+
+func TestParseFromParamsPoll(t *testing.T) {
+	dictionary := []ask.Role{{Name: "alice", Hashtag: "#alice"}}
+	organization := &ask.OrganizationHashtags{PollHashtag: "#poll"}
+
+	post := ParseFromParams(1, vk.PostParams{
+		Text:        "#poll #alice",
+		Attachments: []string{"photo-1_2_key", "poll-1_42"},
+	}, dictionary, organization)
+
+	if post.Kind != Kinds.Poll || post.PollID != 42 {
+		t.Errorf("wrong poll post: %v", post)
+	}
+}
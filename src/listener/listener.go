@@ -57,7 +57,7 @@ func (l *Listener) WallPostNew(ctx context.Context, event events.WallPostNewObje
 
 	vk_post := object.WallWallpost(event)
 
-	err := l.NewPost(&vk_post)
+	err := l.NewPost(ctx, &vk_post)
 	if err != nil {
 		l.log.Errorw("failed to handle new post",
 			"post id", event.ID,
@@ -67,7 +67,7 @@ func (l *Listener) WallPostNew(ctx context.Context, event events.WallPostNewObje
 	//l.admin.WallPostNew(l.group_id, "got: "+event.Text, "", false, time.Now().Add(5*time.Minute).Unix())
 }
 
-func (l *Listener) NewPost(vk_post *object.WallWallpost) error {
+func (l *Listener) NewPost(ctx context.Context, vk_post *object.WallWallpost) error {
 	// i don't care about "copy", "reply" and "postponed"
 	// the last two shouldn't go here anyway
 	if vk_post.PostType != vk.SuggestedPost &&
@@ -75,7 +75,7 @@ func (l *Listener) NewPost(vk_post *object.WallWallpost) error {
 		return nil
 	}
 
-	dictionary, err := l.c.Ask.RolesDictionary()
+	dictionary, err := l.c.Ask.RolesDictionary(ctx)
 	if err != nil {
 		return err
 	}
@@ -91,7 +91,7 @@ func (l *Listener) NewPost(vk_post *object.WallWallpost) error {
 			break
 		}
 
-		err := l.c.Ask.AddOngoingPoll(post.Roles[0].Name, post.ID)
+		err := l.c.Ask.AddOngoingPoll(ctx, ask.WatcherActor, post.Roles[0].Name, post.ID)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"ask-bot/src/ask"
+	"ask-bot/src/ask/db"
 	"ask-bot/src/chatbot"
 	"ask-bot/src/listener"
 	"ask-bot/src/templates"
@@ -10,11 +11,13 @@ import (
 	"ask-bot/src/watcher/events"
 	"ask-bot/src/watcher/postponed"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"go.uber.org/zap"
@@ -29,6 +32,7 @@ func CreateLogger(logdir, filename string) *zap.Logger {
 }
 
 func main() {
+	flags := FlagsFromArgs()
 	config := ConfigFromEnv()
 
 	// development purposes
@@ -57,6 +61,78 @@ func main() {
 	listener_logger := CreateLogger(config.LogDir, "listener.log")
 	watcher_logger := CreateLogger(config.LogDir, "watcher.log")
 
+	db.SetBusyTimeout(config.DBBusyTimeout)
+
+	if flags.Plan {
+		plan, err := ask.PlanMigration(config.DB, config.Schema, config.AllowDeletion)
+		if err != nil {
+			zap.S().Fatalw("failed to plan migration",
+				"error", err)
+		}
+
+		report, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			zap.S().Fatalw("failed to marshal migration plan",
+				"error", err)
+		}
+
+		fmt.Println(string(report))
+		return
+	}
+
+	if flags.Check {
+		err := ask.Check(config.DB, config.Schema, config.AllowDeletion)
+		if err != nil {
+			zap.S().Fatalw("failed to check queries",
+				"error", err)
+		}
+
+		fmt.Println("queries are valid")
+		return
+	}
+
+	if len(flags.Restore) > 0 {
+		err := ask.Restore(flags.Restore, config.DB, config.Schema, config.Backup())
+		if err != nil {
+			zap.S().Fatalw("failed to restore database",
+				"error", err,
+				"snapshot", flags.Restore)
+		}
+
+		fmt.Println("restored", flags.Restore)
+		return
+	}
+
+	if len(flags.Import) > 0 {
+		table, filename, _ := strings.Cut(flags.Import, "=")
+
+		err := ask.Import(config.DB, table, filename)
+		if err != nil {
+			zap.S().Fatalw("failed to import table",
+				"error", err,
+				"table", table,
+				"filename", filename)
+		}
+
+		fmt.Println("imported", table, "from", filename)
+		return
+	}
+
+	if len(flags.Export) > 0 {
+		table, filename, _ := strings.Cut(flags.Export, "=")
+
+		err := ask.Export(config.DB, table, filename)
+		if err != nil {
+			zap.S().Fatalw("failed to export table",
+				"error", err,
+				"table", table,
+				"filename", filename)
+		}
+
+		fmt.Println("exported", table, "to", filename)
+		return
+	}
+
 	// make templates
 	err = templates.NewFromFile(config.Templates)
 	if err != nil {
@@ -75,12 +151,18 @@ func main() {
 	a := ask.New(ask_config)
 
 	// init db + migrate
-	err = a.Init(config.DB, config.Schema, config.AllowDeletion)
+	err = a.Init(config.DB, config.Schema, config.AllowDeletion, config.Backup())
 	if err != nil {
 		zap.S().Fatalw("failed to init ask",
 			"error", err)
 	}
 
+	err = a.InitImages(config.ImagesDir)
+	if err != nil {
+		zap.S().Fatalw("failed to init images store",
+			"error", err)
+	}
+
 	// vk api's init
 	group, err := vk.NewFromFile(config.SecretGroupToken, config.GroupID)
 	if err != nil {
@@ -107,6 +189,7 @@ func main() {
 		NotifyEvent: notify_event,
 	},
 		config.UpdatePostponed,
+		config.BackupInterval,
 		watcher_logger.Sugar())
 
 	c := chatbot.New(&chatbot.Controls{
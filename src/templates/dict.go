@@ -2,6 +2,7 @@ package templates
 
 import (
 	"ask-bot/src/ask"
+	"time"
 )
 
 type MsgAdminReservationConfirmChoiceData struct{}
@@ -21,27 +22,105 @@ type MsgReservationNewData struct{}
 type MsgReservationNewConfirmationData struct{ ask.Role }
 type MsgReservationNewIntroData struct{}
 type MsgReservationNewSuccessData struct{ ask.Role }
+type MsgReservationBannedData struct{ ask.Ban }
+type MsgReservationWaitlistData struct{ Entries []ask.WaitlistEntry }
+type MsgReservationWaitlistJoinedData struct{ ask.Role }
+type MsgReservationWaitlistLeftData struct{ ask.Role }
+type MsgWaitlistOfferData struct{ ask.WaitlistEntry }
+type MsgWaitlistOfferExpiredData struct{ ask.WaitlistEntry }
 type MsgReservationCancelData struct{ ask.Reservation }
 type MsgReservationCancelSuccessData struct{}
 type MsgReservationGreetingRequestData struct{}
+type MsgReservationExtensionData struct{ ask.ExtensionAllowance }
+type MsgReservationExtensionReasonData struct{}
+type MsgReservationExtensionSentData struct{ Days int }
+type MsgReservationExtensionUnavailableData struct{ ask.ExtensionAllowance }
+type MsgReservationListData struct {
+	Reservations []ask.Reservation
+	CanReserve   bool
+}
 type MsgReservationUnderConsiderationData struct{ ask.Reservation }
 type MsgReservationInProgressData struct{ ask.Reservation }
+type MsgReservationUnderReviewData struct{ ask.Reservation }
 type MsgReservationDoneData struct{ ask.Reservation }
 type MsgReservationPollData struct {
 	ask.Reservation
 	Link string
 }
+type MsgReservationReminderData struct {
+	ask.Reservation
+	Hours int
+}
 type MsgMemberDeadlineData struct{ Members []ask.Member }
+type MsgPollEndedData struct {
+	ask.Role
+	// participant to notify
+	VkID     int
+	Decision ask.PollDecisionKind
+	// zero if no one won
+	Winner int
+	// participant is in runoff poll
+	Runoff bool
+}
+type MsgPollsArchiveData struct{ Empty bool }
+type MsgPollsArchiveItemData struct {
+	ask.Role
+	// latest polls go first
+	Polls []ask.PollDecision
+}
 type MsgAdminRolesData struct{}
 type MsgAdminRolesItemData struct{ ask.Role }
 type MsgAdminReservationsData struct{ Reservations []ask.Reservation }
-type MsgAdminReservationConsiderateData struct{ ask.Reservation }
+type MsgAdminReservationConsiderateData struct {
+	ask.Reservation
+	Archive []ask.ArchivedReservation
+}
 type MsgAdminReservationConsideratedData struct {
 	Reservation ask.Reservation
 	Decision    bool
+	Reason      string
 }
 type MsgAdminReservationConsideratedNotifyData MsgAdminReservationConsideratedData
+type MsgAdminReservationExtensionData struct{ ask.ReservationExtension }
+type MsgAdminReservationExtendedData struct {
+	Extension ask.ReservationExtension
+	Decision  bool
+	Deadline  time.Time
+}
+type MsgAdminReservationExtendedNotifyData MsgAdminReservationExtendedData
+type MsgAdminReservationExtendedRoleNotifyData MsgAdminReservationExtendedData
+type MsgAdminReservationReviewData struct{ ask.Reservation }
+type MsgAdminReservationReviewedData struct {
+	Reservation ask.Reservation
+	Decision    bool
+	Comment     string
+}
+type MsgAdminReservationReviewedNotifyData MsgAdminReservationReviewedData
 type MsgAdminReservationDeletedData struct{ ask.Reservation }
+type MsgAdminReservationCreatedData struct{ ask.Reservation }
+type MsgAdminReservationCreatedNotifyData MsgAdminReservationCreatedData
+type MsgAdminAuditData struct{}
+type MsgAdminAuditRecordsData struct{ Records []ask.AuditRecord }
+type MsgAdminFunnelData struct{ ask.Funnel }
+type MsgAdminBansData struct{ Bans []ask.Ban }
+type MsgAdminBannedData struct{ ask.Ban }
+type MsgAdminBannedNotifyData MsgAdminBannedData
+type MsgAdminBanLiftedData struct{ ask.Ban }
+type MsgAdminBanLiftedNotifyData MsgAdminBanLiftedData
+type MsgAdminPollDecisionsData struct{ Decisions []ask.PollDecision }
+type MsgCommandsData struct{ IsAdmin bool }
+type MsgCommandUnknownData struct{}
+type MsgCommandReservationNotFoundData struct {
+	VkID int
+	// several reservations are suitable
+	Reservations []ask.Reservation
+}
+type MsgCommandUserRequiredData struct{}
+type MsgCommandUserNotFoundData struct{ Link string }
+type MsgCommandReservationLimitData struct {
+	Prefix string
+	Limit  int
+}
 type PostPollData struct {
 	PollHashtag string
 	Poll        ask.PendingPoll
@@ -50,9 +129,18 @@ type PostPollLabelData struct {
 }
 type PostPollAnswerData struct {
 	Index int
+	// neutral answer is not counted, it has own template
 	Count int
 	Value int
-	// TO-DO ask config if neutral answer is presented
 }
+type PostPollNeutralAnswerData struct{}
+type CmdHelpData struct{}
+type CmdReservationData struct{}
+type CmdPointsData struct{}
+type CmdDeadlineData struct{}
+type CmdCancelData struct{}
+type CmdAdminData struct{}
+type CmdConfirmData struct{}
+type CmdDeclineData struct{}
 
-var Templates = map[TemplateID]Template{MsgGreeting: {Type: (*MsgGreetingData)(nil)}, MsgPoints: {Type: (*MsgPointsData)(nil)}, MsgPointsNoHistory: {Type: (*MsgPointsNoHistoryData)(nil)}, MsgPointsEvent: {Type: (*MsgPointsEventData)(nil)}, MsgPointsShortHistory: {Type: (*MsgPointsShortHistoryData)(nil)}, MsgReservationNew: {Type: (*MsgReservationNewData)(nil)}, MsgReservationNewConfirmation: {Type: (*MsgReservationNewConfirmationData)(nil)}, MsgReservationNewIntro: {Type: (*MsgReservationNewIntroData)(nil)}, MsgReservationNewSuccess: {Type: (*MsgReservationNewSuccessData)(nil)}, MsgReservationCancel: {Type: (*MsgReservationCancelData)(nil)}, MsgReservationCancelSuccess: {Type: (*MsgReservationCancelSuccessData)(nil)}, MsgReservationGreetingRequest: {Type: (*MsgReservationGreetingRequestData)(nil)}, MsgReservationUnderConsideration: {Type: (*MsgReservationUnderConsiderationData)(nil)}, MsgReservationInProgress: {Type: (*MsgReservationInProgressData)(nil)}, MsgReservationDone: {Type: (*MsgReservationDoneData)(nil)}, MsgReservationPoll: {Type: (*MsgReservationPollData)(nil)}, MsgMemberDeadline: {Type: (*MsgMemberDeadlineData)(nil)}, MsgAdminRoles: {Type: (*MsgAdminRolesData)(nil)}, MsgAdminRolesItem: {Type: (*MsgAdminRolesItemData)(nil)}, MsgAdminReservations: {Type: (*MsgAdminReservationsData)(nil)}, MsgAdminReservationConsiderate: {Type: (*MsgAdminReservationConsiderateData)(nil)}, MsgAdminReservationConsiderated: {Type: (*MsgAdminReservationConsideratedData)(nil)}, MsgAdminReservationConsideratedNotify: {Type: (*MsgAdminReservationConsideratedNotifyData)(nil)}, MsgAdminReservationDeleted: {Type: (*MsgAdminReservationDeletedData)(nil)}, PostPoll: {Type: (*PostPollData)(nil)}, PostPollLabel: {Type: (*PostPollLabelData)(nil)}, PostPollAnswer: {Type: (*PostPollAnswerData)(nil)}}
+var Templates = map[TemplateID]Template{MsgGreeting: {Type: (*MsgGreetingData)(nil)}, MsgPoints: {Type: (*MsgPointsData)(nil)}, MsgPointsNoHistory: {Type: (*MsgPointsNoHistoryData)(nil)}, MsgPointsEvent: {Type: (*MsgPointsEventData)(nil)}, MsgPointsShortHistory: {Type: (*MsgPointsShortHistoryData)(nil)}, MsgReservationNew: {Type: (*MsgReservationNewData)(nil)}, MsgReservationNewConfirmation: {Type: (*MsgReservationNewConfirmationData)(nil)}, MsgReservationNewIntro: {Type: (*MsgReservationNewIntroData)(nil)}, MsgReservationNewSuccess: {Type: (*MsgReservationNewSuccessData)(nil)}, MsgReservationBanned: {Type: (*MsgReservationBannedData)(nil)}, MsgReservationWaitlist: {Type: (*MsgReservationWaitlistData)(nil)}, MsgReservationWaitlistJoined: {Type: (*MsgReservationWaitlistJoinedData)(nil)}, MsgReservationWaitlistLeft: {Type: (*MsgReservationWaitlistLeftData)(nil)}, MsgWaitlistOffer: {Type: (*MsgWaitlistOfferData)(nil)}, MsgWaitlistOfferExpired: {Type: (*MsgWaitlistOfferExpiredData)(nil)}, MsgReservationCancel: {Type: (*MsgReservationCancelData)(nil)}, MsgReservationCancelSuccess: {Type: (*MsgReservationCancelSuccessData)(nil)}, MsgReservationGreetingRequest: {Type: (*MsgReservationGreetingRequestData)(nil)}, MsgReservationExtension: {Type: (*MsgReservationExtensionData)(nil)}, MsgReservationExtensionReason: {Type: (*MsgReservationExtensionReasonData)(nil)}, MsgReservationExtensionSent: {Type: (*MsgReservationExtensionSentData)(nil)}, MsgReservationExtensionUnavailable: {Type: (*MsgReservationExtensionUnavailableData)(nil)}, MsgReservationList: {Type: (*MsgReservationListData)(nil)}, MsgReservationUnderConsideration: {Type: (*MsgReservationUnderConsiderationData)(nil)}, MsgReservationInProgress: {Type: (*MsgReservationInProgressData)(nil)}, MsgReservationUnderReview: {Type: (*MsgReservationUnderReviewData)(nil)}, MsgReservationDone: {Type: (*MsgReservationDoneData)(nil)}, MsgReservationPoll: {Type: (*MsgReservationPollData)(nil)}, MsgReservationReminder: {Type: (*MsgReservationReminderData)(nil)}, MsgMemberDeadline: {Type: (*MsgMemberDeadlineData)(nil)}, MsgPollEnded: {Type: (*MsgPollEndedData)(nil)}, MsgPollsArchive: {Type: (*MsgPollsArchiveData)(nil)}, MsgPollsArchiveItem: {Type: (*MsgPollsArchiveItemData)(nil)}, MsgAdminRoles: {Type: (*MsgAdminRolesData)(nil)}, MsgAdminRolesItem: {Type: (*MsgAdminRolesItemData)(nil)}, MsgAdminReservations: {Type: (*MsgAdminReservationsData)(nil)}, MsgAdminReservationConsiderate: {Type: (*MsgAdminReservationConsiderateData)(nil)}, MsgAdminReservationConsiderated: {Type: (*MsgAdminReservationConsideratedData)(nil)}, MsgAdminReservationConsideratedNotify: {Type: (*MsgAdminReservationConsideratedNotifyData)(nil)}, MsgAdminReservationExtension: {Type: (*MsgAdminReservationExtensionData)(nil)}, MsgAdminReservationExtended: {Type: (*MsgAdminReservationExtendedData)(nil)}, MsgAdminReservationExtendedNotify: {Type: (*MsgAdminReservationExtendedNotifyData)(nil)}, MsgAdminReservationExtendedRoleNotify: {Type: (*MsgAdminReservationExtendedRoleNotifyData)(nil)}, MsgAdminReservationReview: {Type: (*MsgAdminReservationReviewData)(nil)}, MsgAdminReservationReviewed: {Type: (*MsgAdminReservationReviewedData)(nil)}, MsgAdminReservationReviewedNotify: {Type: (*MsgAdminReservationReviewedNotifyData)(nil)}, MsgAdminReservationDeleted: {Type: (*MsgAdminReservationDeletedData)(nil)}, MsgAdminReservationCreated: {Type: (*MsgAdminReservationCreatedData)(nil)}, MsgAdminReservationCreatedNotify: {Type: (*MsgAdminReservationCreatedNotifyData)(nil)}, MsgAdminAudit: {Type: (*MsgAdminAuditData)(nil)}, MsgAdminAuditRecords: {Type: (*MsgAdminAuditRecordsData)(nil)}, MsgAdminFunnel: {Type: (*MsgAdminFunnelData)(nil)}, MsgAdminBans: {Type: (*MsgAdminBansData)(nil)}, MsgAdminBanned: {Type: (*MsgAdminBannedData)(nil)}, MsgAdminBannedNotify: {Type: (*MsgAdminBannedNotifyData)(nil)}, MsgAdminBanLifted: {Type: (*MsgAdminBanLiftedData)(nil)}, MsgAdminBanLiftedNotify: {Type: (*MsgAdminBanLiftedNotifyData)(nil)}, MsgAdminPollDecisions: {Type: (*MsgAdminPollDecisionsData)(nil)}, MsgCommands: {Type: (*MsgCommandsData)(nil)}, MsgCommandUnknown: {Type: (*MsgCommandUnknownData)(nil)}, MsgCommandReservationNotFound: {Type: (*MsgCommandReservationNotFoundData)(nil)}, MsgCommandUserRequired: {Type: (*MsgCommandUserRequiredData)(nil)}, MsgCommandUserNotFound: {Type: (*MsgCommandUserNotFoundData)(nil)}, MsgCommandReservationLimit: {Type: (*MsgCommandReservationLimitData)(nil)}, PostPoll: {Type: (*PostPollData)(nil)}, PostPollLabel: {Type: (*PostPollLabelData)(nil)}, PostPollAnswer: {Type: (*PostPollAnswerData)(nil)}, PostPollNeutralAnswer: {Type: (*PostPollNeutralAnswerData)(nil)}, CmdHelp: {Type: (*CmdHelpData)(nil)}, CmdReservation: {Type: (*CmdReservationData)(nil)}, CmdPoints: {Type: (*CmdPointsData)(nil)}, CmdDeadline: {Type: (*CmdDeadlineData)(nil)}, CmdCancel: {Type: (*CmdCancelData)(nil)}, CmdAdmin: {Type: (*CmdAdminData)(nil)}, CmdConfirm: {Type: (*CmdConfirmData)(nil)}, CmdDecline: {Type: (*CmdDeclineData)(nil)}}
@@ -25,17 +25,35 @@ const (
 	MsgReservationNewConfirmation TemplateID = "msg_reservation_new_confirmation"
 	MsgReservationNewIntro        TemplateID = "msg_reservation_new_intro"
 	MsgReservationNewSuccess      TemplateID = "msg_reservation_new_success"
+	MsgReservationBanned          TemplateID = "msg_reservation_banned"
+
+	MsgReservationWaitlist       TemplateID = "msg_reservation_waitlist"
+	MsgReservationWaitlistJoined TemplateID = "msg_reservation_waitlist_joined"
+	MsgReservationWaitlistLeft   TemplateID = "msg_reservation_waitlist_left"
+	MsgWaitlistOffer             TemplateID = "msg_waitlist_offer"
+	MsgWaitlistOfferExpired      TemplateID = "msg_waitlist_offer_expired"
 
 	MsgReservationCancel          TemplateID = "msg_reservation_cancel"
 	MsgReservationCancelSuccess   TemplateID = "msg_reservation_cancel_success"
 	MsgReservationGreetingRequest TemplateID = "msg_reservation_greeting_request"
 
+	MsgReservationExtension            TemplateID = "msg_reservation_extension"
+	MsgReservationExtensionReason      TemplateID = "msg_reservation_extension_reason"
+	MsgReservationExtensionSent        TemplateID = "msg_reservation_extension_sent"
+	MsgReservationExtensionUnavailable TemplateID = "msg_reservation_extension_unavailable"
+	MsgReservationList                 TemplateID = "msg_reservation_list"
+
 	MsgReservationUnderConsideration TemplateID = "msg_reservation_under_consideration"
 	MsgReservationInProgress         TemplateID = "msg_reservation_in_progress"
+	MsgReservationUnderReview        TemplateID = "msg_reservation_under_review"
 	MsgReservationDone               TemplateID = "msg_reservation_done"
 	MsgReservationPoll               TemplateID = "msg_reservation_poll"
+	MsgReservationReminder           TemplateID = "msg_reservation_reminder"
 
-	MsgMemberDeadline TemplateID = "msg_member_deadline"
+	MsgMemberDeadline   TemplateID = "msg_member_deadline"
+	MsgPollEnded        TemplateID = "msg_poll_ended"
+	MsgPollsArchive     TemplateID = "msg_polls_archive"
+	MsgPollsArchiveItem TemplateID = "msg_polls_archive_item"
 
 	MsgAdminRoles                         TemplateID = "msg_admin_roles"
 	MsgAdminRolesItem                     TemplateID = "msg_admin_roles_item"
@@ -43,12 +61,49 @@ const (
 	MsgAdminReservationConsiderate        TemplateID = "msg_admin_reservation_considerate"
 	MsgAdminReservationConsiderated       TemplateID = "msg_admin_reservation_considerated"
 	MsgAdminReservationConsideratedNotify TemplateID = "msg_admin_reservation_considerated_notify"
+	MsgAdminReservationExtension          TemplateID = "msg_admin_reservation_extension"
+	MsgAdminReservationExtended           TemplateID = "msg_admin_reservation_extended"
+	MsgAdminReservationExtendedNotify     TemplateID = "msg_admin_reservation_extended_notify"
+	MsgAdminReservationExtendedRoleNotify TemplateID = "msg_admin_reservation_extended_role_notify"
+	MsgAdminReservationReview             TemplateID = "msg_admin_reservation_review"
+	MsgAdminReservationReviewed           TemplateID = "msg_admin_reservation_reviewed"
+	MsgAdminReservationReviewedNotify     TemplateID = "msg_admin_reservation_reviewed_notify"
 	MsgAdminReservationDeleted            TemplateID = "msg_admin_reservation_deleted"
+	MsgAdminReservationCreated            TemplateID = "msg_admin_reservation_created"
+	MsgAdminReservationCreatedNotify      TemplateID = "msg_admin_reservation_created_notify"
+	MsgAdminAudit                         TemplateID = "msg_admin_audit"
+	MsgAdminAuditRecords                  TemplateID = "msg_admin_audit_records"
+	MsgAdminFunnel                        TemplateID = "msg_admin_funnel"
+	MsgAdminBans                          TemplateID = "msg_admin_bans"
+	MsgAdminBanned                        TemplateID = "msg_admin_banned"
+	MsgAdminBannedNotify                  TemplateID = "msg_admin_banned_notify"
+	MsgAdminBanLifted                     TemplateID = "msg_admin_ban_lifted"
+	MsgAdminBanLiftedNotify               TemplateID = "msg_admin_ban_lifted_notify"
+	MsgAdminPollDecisions                 TemplateID = "msg_admin_poll_decisions"
+	MsgCommands                           TemplateID = "msg_commands"
+	MsgCommandUnknown                     TemplateID = "msg_command_unknown"
+	MsgCommandReservationNotFound         TemplateID = "msg_command_reservation_not_found"
+	MsgCommandUserRequired                TemplateID = "msg_command_user_required"
+	MsgCommandUserNotFound                TemplateID = "msg_command_user_not_found"
+	MsgCommandReservationLimit            TemplateID = "msg_command_reservation_limit"
 )
 
 const (
 	// post poll template should contain roles & poll hashtags!!!
-	PostPoll       TemplateID = "post_poll"
-	PostPollLabel  TemplateID = "post_poll_label"
-	PostPollAnswer TemplateID = "post_poll_answer"
+	PostPoll              TemplateID = "post_poll"
+	PostPollLabel         TemplateID = "post_poll_label"
+	PostPollAnswer        TemplateID = "post_poll_answer"
+	PostPollNeutralAnswer TemplateID = "post_poll_neutral_answer"
+)
+
+const (
+	// every text is an alias of command, commands are sent with slash
+	CmdHelp        TemplateID = "cmd_help"
+	CmdReservation TemplateID = "cmd_reservation"
+	CmdPoints      TemplateID = "cmd_points"
+	CmdDeadline    TemplateID = "cmd_deadline"
+	CmdCancel      TemplateID = "cmd_cancel"
+	CmdAdmin       TemplateID = "cmd_admin"
+	CmdConfirm     TemplateID = "cmd_confirm"
+	CmdDecline     TemplateID = "cmd_decline"
 )
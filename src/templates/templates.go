@@ -99,10 +99,40 @@ func NewFromFile(filename string) error {
 }
 
 func ParseTemplate(id TemplateID, data interface{}) (string, error) {
+	ts, err := lookup(id, data)
+	if err != nil {
+		return "", err
+	}
+
+	// choose template
+	t := ts.Templates[r.Intn(len(ts.Templates))]
+
+	return execute(id, t, data)
+}
+
+// all texts of template, e.g. aliases of command
+func ParseVariants(id TemplateID, data interface{}) ([]string, error) {
+	ts, err := lookup(id, data)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make([]string, len(ts.Templates))
+	for i, t := range ts.Templates {
+		variants[i], err = execute(id, t, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return variants, nil
+}
+
+func lookup(id TemplateID, data interface{}) (Template, error) {
 	ts, ok := Templates[id]
 	if !ok {
 		err := errors.New("no template with such id")
-		return "", zaperr.Wrap(err, "",
+		return Template{}, zaperr.Wrap(err, "",
 			zap.String("id", string(id)))
 	}
 
@@ -116,15 +146,16 @@ func ParseTemplate(id TemplateID, data interface{}) (string, error) {
 
 	if target_type != actual_type {
 		err := errors.New("invalid type of data for template")
-		return "", zaperr.Wrap(err, "",
+		return Template{}, zaperr.Wrap(err, "",
 			zap.String("id", string(id)),
 			zap.Any("expected type", ts.Type),
 			zap.Any("data", data))
 	}
 
-	// choose template
-	t := ts.Templates[r.Intn(len(ts.Templates))]
+	return ts, nil
+}
 
+func execute(id TemplateID, t *template.Template, data interface{}) (string, error) {
 	wr := bytes.NewBufferString("")
 	err := t.Execute(wr, data)
 	if err != nil {
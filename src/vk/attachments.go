@@ -43,6 +43,18 @@ func (v *VK) UploadPhotoToWall(file io.Reader) ([]object.PhotosPhoto, error) {
 	return response, nil
 }
 
+// photo is available only in dialog with peer
+func (v *VK) UploadPhotoToMessages(peer_id int, file io.Reader) ([]object.PhotosPhoto, error) {
+	response, err := v.api.UploadMessagesPhoto(peer_id, file)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to upload photo to messages",
+			zap.Int("peer_id", peer_id),
+			zap.Any("response", response))
+	}
+
+	return response, nil
+}
+
 // TO-DO maybe they all should have access key?
 func ToAttachments(attachments []object.MessagesMessageAttachment) string {
 	result := []string{}
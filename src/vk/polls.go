@@ -38,3 +38,25 @@ func (v *VK) CreatePoll(question string, answers []string, is_anonymous bool, en
 	poll := object.PollsPoll(response)
 	return &poll, nil
 }
+
+// poll with actual votes
+func (v *VK) PollByID(owner_id int, poll_id int) (*object.PollsPoll, error) {
+	params := api.Params{
+		"owner_id": owner_id,
+		"poll_id":  poll_id,
+	}
+
+	response, err := v.api.PollsGetByID(params)
+	if err != nil {
+		return nil, zaperr.Wrap(err, "failed to get poll by id",
+			zap.Any("params", params),
+			zap.Any("response", response))
+	}
+
+	zap.S().Debugw("successfully get poll by id",
+		"params", params,
+		"response", response)
+
+	poll := object.PollsPoll(response)
+	return &poll, nil
+}
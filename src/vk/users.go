@@ -0,0 +1,50 @@
+package vk
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/SevereCloud/vksdk/v2/api"
+	"github.com/hori-ryota/zaperr"
+	"go.uber.org/zap"
+)
+
+// ResolveUser accepts vk id, mention or profile link and returns user id,
+// 0 is returned if there is no such user.
+func (v *VK) ResolveUser(link string) (int, error) {
+	name := strings.TrimSpace(link)
+	name = strings.TrimPrefix(name, "https://")
+	name = strings.TrimPrefix(name, "http://")
+	name = strings.TrimPrefix(name, "m.")
+	name = strings.TrimPrefix(name, "vk.com/")
+	name = strings.TrimPrefix(name, "@")
+	name = strings.TrimSuffix(name, "/")
+
+	if id, err := strconv.Atoi(strings.TrimPrefix(name, "id")); err == nil {
+		if id <= 0 {
+			return 0, nil
+		}
+		return id, nil
+	}
+
+	if len(name) == 0 || strings.ContainsAny(name, "/?# ") {
+		return 0, nil
+	}
+
+	params := api.Params{
+		"screen_name": name,
+	}
+
+	response, err := v.api.UtilsResolveScreenName(params)
+	if err != nil {
+		return 0, zaperr.Wrap(err, "failed to resolve vk screen name",
+			zap.Any("params", params),
+			zap.Any("response", response))
+	}
+
+	if response.Type != "user" {
+		return 0, nil
+	}
+
+	return response.ObjectID, nil
+}
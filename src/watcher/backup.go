@@ -0,0 +1,7 @@
+package watcher
+
+import "context"
+
+func (c *Controls) BackupDatabase(ctx context.Context) error {
+	return c.Ask.Backup(ctx)
+}
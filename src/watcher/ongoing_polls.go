@@ -2,14 +2,19 @@ package watcher
 
 import (
 	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+	"slices"
 	"time"
 
 	"github.com/SevereCloud/vksdk/v2/object"
+	"go.uber.org/zap"
 )
 
-func (c *Controls) CheckOngoingPolls() error {
+func (c *Controls) CheckOngoingPolls(ctx context.Context) error {
 	// get post ids
-	polls, err := c.Ask.OngoingPolls()
+	polls, err := c.Ask.OngoingPolls(ctx)
 	if err != nil {
 		return err
 	}
@@ -29,27 +34,110 @@ func (c *Controls) CheckOngoingPolls() error {
 		return err
 	}
 
-	// check polls
+	// deleted posts are not returned, so order of posts is not the same as polls
+	by_id := make(map[int]*object.WallWallpost, len(posts))
 	for i := range posts {
-		for _, attachment := range posts[i].Attachments {
+		by_id[posts[i].ID] = &posts[i]
+	}
+
+	// check polls, broken poll should not block others
+	for i := range polls {
+		post, ok := by_id[polls[i].Post]
+		if !ok {
+			zap.S().Warnw("post of ongoing poll is deleted; drop poll",
+				"poll", polls[i])
+
+			err := c.Ask.DropPoll(ctx, ask.WatcherActor, polls[i])
+			if err != nil {
+				zap.S().Errorw("failed to drop poll",
+					"error", err,
+					"poll", polls[i])
+			}
+			continue
+		}
+
+		for _, attachment := range post.Attachments {
 			if attachment.Type != object.AttachmentTypePoll {
 				continue
 			}
 
 			if time.Now().After(time.Unix(int64(attachment.Poll.EndDate), 0)) {
-				err := c.endPoll(&posts[i], &polls[i])
+				err := c.endPoll(ctx, &polls[i], &attachment.Poll)
 				if err != nil {
-					return err
+					zap.S().Errorw("failed to end poll",
+						"error", err,
+						"poll", polls[i])
 				}
 			}
 		}
 	}
 
-	// if finished then create greeting
 	return nil
 }
 
-// TO-DO: finish
-func (c *Controls) endPoll(post *object.WallWallpost, poll *ask.OngoingPoll) error {
+// final votes are taken from vk, answers are mapped to vk ids by cache
+func (c *Controls) endPoll(ctx context.Context, poll *ask.OngoingPoll, vk_poll *object.PollsPoll) error {
+	final, err := c.Admin.PollByID(vk_poll.OwnerID, vk_poll.ID)
+	if err != nil {
+		return err
+	}
+
+	results := make([]ask.PollResult, len(final.Answers))
+	for i, answer := range final.Answers {
+		value, ok, err := c.Ask.LoadPollAnswer(ctx, final.ID, answer.ID)
+		if err != nil {
+			return err
+		}
+
+		// votes of unknown answer could not be counted, so poll is held again
+		if !ok {
+			zap.S().Warnw("answer of ended poll is not cached; drop poll",
+				"poll", poll,
+				"answer", answer)
+
+			err := c.Ask.DropPoll(ctx, ask.WatcherActor, *poll)
+			if err != nil {
+				return err
+			}
+
+			return c.Ask.DeletePollAnswers(ctx, final.ID)
+		}
+
+		results[i] = ask.PollResult{
+			Value: value,
+			Votes: answer.Votes,
+		}
+	}
+
+	outcome, err := c.Ask.EndPoll(ctx, ask.WatcherActor, *poll, final.ID, results)
+	if err != nil {
+		return err
+	}
+
+	role, err := c.Ask.Role(ctx, poll.Role)
+	if err != nil {
+		return err
+	}
+
+	for _, vk_id := range outcome.Participants {
+		message, err := ts.ParseTemplate(
+			ts.MsgPollEnded,
+			ts.MsgPollEndedData{
+				Role:     role,
+				VkID:     vk_id,
+				Decision: outcome.Decision,
+				Winner:   outcome.Winner,
+				Runoff:   slices.Contains(outcome.Runoff, vk_id),
+			})
+		if err != nil {
+			return err
+		}
+
+		c.NotifyUser <- &vk.MessageParams{
+			Id:   vk_id,
+			Text: message,
+		}
+	}
+
 	return nil
 }
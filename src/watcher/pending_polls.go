@@ -6,21 +6,23 @@ import (
 	"ask-bot/src/datatypes/posts"
 	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/SevereCloud/vksdk/v2/object"
 	"github.com/hori-ryota/zaperr"
 	"go.uber.org/zap"
 )
 
-func (c *Controls) CheckPendingPolls() error {
+func (c *Controls) CheckPendingPolls(ctx context.Context) error {
 	polls := c.Postponed.PostsKind(posts.Kinds.Poll)
 
 	// order by name
-	pending_polls, err := c.Ask.PendingPolls()
+	pending_polls, err := c.Ask.PendingPolls(ctx)
 	if err != nil {
 		return err
 	}
@@ -45,19 +47,43 @@ func (c *Controls) CheckPendingPolls() error {
 		if err != nil {
 			return err
 		}
+
+		// deleted polls are never ended, so their answers are purged here
+		for _, poll := range delete {
+			if poll.PollID == 0 {
+				continue
+			}
+
+			err = c.Ask.DeletePollAnswers(ctx, poll.PollID)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	if len(pending_polls) == 0 {
 		return nil
 	}
 
+	// runoff polls take the closest slots
+	slices.SortStableFunc(pending_polls, func(a, b ask.PendingPoll) int {
+		switch {
+		case a.Runoff == b.Runoff:
+			return 0
+		case a.Runoff:
+			return -1
+		default:
+			return 1
+		}
+	})
+
 	new := []vk.PostParams{}
 
 	// when to post
 	begin := time.Now()
-	end := begin.Add(14 * 24 * time.Hour)
+	end := begin.Add(c.Ask.PollHorizon())
 
-	slots, err := c.Ask.Schedule(ask.TimeslotKinds.Polls, begin, end)
+	slots, err := c.Ask.Schedule(ctx, ask.TimeslotKinds.Polls, begin, end)
 	if err != nil {
 		return err
 	}
@@ -67,7 +93,7 @@ func (c *Controls) CheckPendingPolls() error {
 	// create polls
 	// TO-DO maybe sort polls by timestamp or smth
 	for i := 0; i < len(slots) && i < len(pending_polls); i++ {
-		poll, err := c.createPoll(pending_polls[i], slots[i])
+		poll, err := c.createPoll(ctx, pending_polls[i], slots[i])
 		if err != nil {
 			return err
 		}
@@ -75,21 +101,21 @@ func (c *Controls) CheckPendingPolls() error {
 		new = append(new, poll)
 	}
 
-	return c.Postponed.AddPosts(c.PostponedControls(), new)
+	return c.Postponed.AddPosts(ctx, c.PostponedControls(), new)
 }
 
-func (c *Controls) createPoll(poll ask.PendingPoll, date time.Time) (vk.PostParams, error) {
+func (c *Controls) createPoll(ctx context.Context, poll ask.PendingPoll, date time.Time) (vk.PostParams, error) {
 	text, err := c.pollText(poll)
 	if err != nil {
 		return vk.PostParams{}, err
 	}
 
-	images, err := c.uploadGreetings(poll.Greetings)
+	images, err := c.uploadGreetings(ctx, poll.Greetings)
 	if err != nil {
 		return vk.PostParams{}, err
 	}
 
-	vk_poll, err := c.createVkPoll(poll, date)
+	vk_poll, err := c.createVkPoll(ctx, poll, date)
 	if err != nil {
 		return vk.PostParams{}, err
 	}
@@ -112,19 +138,19 @@ func (c *Controls) pollText(poll ask.PendingPoll) (string, error) {
 }
 
 // TO-DO not sure if it is fast way, maybe some kind of bulk upload is useful
-func (c *Controls) uploadGreetings(greetings ask.Greetings) ([]string, error) {
+func (c *Controls) uploadGreetings(ctx context.Context, greetings ask.Greetings) ([]string, error) {
 	var attachments []string
 
 	for _, greeting := range greetings {
 		for _, image := range greeting {
-			file, err := http.Get(image)
+			file, err := c.Ask.OpenImage(ctx, image)
 			if err != nil {
-				return nil, zaperr.Wrap(err, "failed to download image",
-					zap.String("url", image))
+				return nil, err
 			}
 
 			// TO-DO admin or group?
-			photos, err := c.Admin.UploadPhotoToWall(file.Body)
+			photos, err := c.Admin.UploadPhotoToWall(file)
+			file.Close()
 			if err != nil {
 				return nil, err
 			}
@@ -139,7 +165,7 @@ func (c *Controls) uploadGreetings(greetings ask.Greetings) ([]string, error) {
 	return attachments, nil
 }
 
-func (c *Controls) createVkPoll(poll ask.PendingPoll, date time.Time) (string, error) {
+func (c *Controls) createVkPoll(ctx context.Context, poll ask.PendingPoll, date time.Time) (string, error) {
 	label, err := ts.ParseTemplate(
 		ts.PostPollLabel,
 		ts.PostPollLabelData{},
@@ -148,17 +174,35 @@ func (c *Controls) createVkPoll(poll ask.PendingPoll, date time.Time) (string, e
 		return "", err
 	}
 
-	answers := poll.Answers()
+	answers := poll.Answers(c.Ask.NeutralPollAnswer())
+
+	// neutral answer is not counted
+	count := slices.IndexFunc(answers, func(a ask.PollAnswer) bool {
+		return a.Value == ask.NeutralPollAnswer
+	})
+	if count == -1 {
+		count = len(answers)
+	}
 
 	for i := range answers {
-		answer, err := ts.ParseTemplate(
-			ts.PostPollAnswer,
-			ts.PostPollAnswerData{
-				Index: i,
-				Count: len(answers),
-				Value: answers[i].Value,
-			},
-		)
+		var answer string
+		var err error
+
+		if answers[i].Value == ask.NeutralPollAnswer {
+			answer, err = ts.ParseTemplate(
+				ts.PostPollNeutralAnswer,
+				ts.PostPollNeutralAnswerData{},
+			)
+		} else {
+			answer, err = ts.ParseTemplate(
+				ts.PostPollAnswer,
+				ts.PostPollAnswerData{
+					Index: i,
+					Count: count,
+					Value: answers[i].Value,
+				},
+			)
+		}
 
 		if err != nil {
 			return "", err
@@ -167,31 +211,42 @@ func (c *Controls) createVkPoll(poll ask.PendingPoll, date time.Time) (string, e
 		answers[i].Label = answer
 	}
 
-	// add config for poll duration
 	vk_poll, err := c.Admin.CreatePoll(label,
 		functional.Map(answers, func(a ask.PollAnswer) string { return a.Label }),
 		true,
-		date.Add(24*time.Hour).Unix())
+		date.Add(c.Ask.PollDuration(poll.Role)).Unix())
 
 	if err != nil {
 		return "", err
 	}
 
 	// add to db info about poll
-	for _, vk_answer := range vk_poll.Answers {
-
-		for j := range answers {
-			if strings.Compare(answers[j].Label, vk_answer.Text) == 0 {
-				answers[j].ID = vk_answer.ID
-				break
-			}
-		}
+	err = assignAnswerIDs(answers, vk_poll.Answers)
+	if err != nil {
+		return "", err
 	}
 
-	err = c.Ask.SavePollAnswers(vk_poll.ID, answers)
+	err = c.Ask.SavePollAnswers(ctx, vk_poll.ID, answers)
 	if err != nil {
 		return "", err
 	}
 
 	return vk_poll.ToAttachment(), nil
 }
+
+// labels of candidates are the same, so answers are mapped by position:
+// vk returns answers in order of creation
+func assignAnswerIDs(answers []ask.PollAnswer, vk_answers []object.PollsAnswer) error {
+	if len(answers) != len(vk_answers) {
+		err := errors.New("number of vk poll answers is not the same as created")
+		return zaperr.Wrap(err, "",
+			zap.Any("answers", answers),
+			zap.Any("vk answers", vk_answers))
+	}
+
+	for i := range answers {
+		answers[i].ID = vk_answers[i].ID
+	}
+
+	return nil
+}
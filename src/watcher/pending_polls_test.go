@@ -0,0 +1,52 @@
+package watcher
+
+import (
+	"ask-bot/src/ask"
+	"testing"
+
+	"github.com/SevereCloud/vksdk/v2/object"
+)
+
+func TestAssignAnswerIDs(t *testing.T) {
+	poll := ask.PendingPoll{
+		Count:        3,
+		Participants: ask.VkIDs{10, 20, 30},
+	}
+
+	// every candidate has the same label
+	answers := poll.Answers(true)
+	for i := range answers {
+		answers[i].Label = "Да"
+	}
+
+	vk_answers := []object.PollsAnswer{
+		{ID: 101, Text: "Да"},
+		{ID: 102, Text: "Да"},
+		{ID: 103, Text: "Да"},
+		{ID: 104, Text: "Да"},
+		{ID: 105, Text: "Да"},
+	}
+
+	err := assignAnswerIDs(answers, vk_answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]int{
+		101: 10,
+		102: 20,
+		103: 30,
+		104: ask.NoOnePollAnswer,
+		105: ask.NeutralPollAnswer,
+	}
+	for _, answer := range answers {
+		if expected[answer.ID] != answer.Value {
+			t.Errorf("wrong answer id %d of value %d", answer.ID, answer.Value)
+		}
+	}
+
+	err = assignAnswerIDs(answers, vk_answers[:2])
+	if err == nil {
+		t.Error("missing vk answers should fail")
+	}
+}
@@ -1,6 +1,9 @@
 package watcher
 
-import "ask-bot/src/datatypes/posts"
+import (
+	"ask-bot/src/datatypes/posts"
+	"context"
+)
 
 // make diff tasks like check polls, check
 // func (w *Watcher) updatePostponed() error {
@@ -10,11 +13,11 @@ import "ask-bot/src/datatypes/posts"
 // 	})
 // }
 
-func (c *Controls) UpdatePostponed() error {
-	return c.Postponed.Update(c.PostponedControls())
+func (c *Controls) UpdatePostponed(ctx context.Context) error {
+	return c.Postponed.Update(ctx, c.PostponedControls())
 }
 
-func (c *Controls) DeleteInvalidPostponed() error {
+func (c *Controls) DeleteInvalidPostponed(ctx context.Context) error {
 	invalid := c.Postponed.PostsKind(posts.Kinds.Invalid)
 
 	ids := make([]posts.Post, len(invalid))
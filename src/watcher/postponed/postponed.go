@@ -5,6 +5,7 @@ import (
 	"ask-bot/src/datatypes/posts"
 	"ask-bot/src/datatypes/schedule"
 	"ask-bot/src/vk"
+	"context"
 	"sync"
 )
 
@@ -29,7 +30,7 @@ func New(v *vk.VK) *Postponed {
 // update posts & schedule
 
 // full reupdate of data
-func (p *Postponed) Update(c *Controls) error {
+func (p *Postponed) Update(ctx context.Context, c *Controls) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -38,7 +39,7 @@ func (p *Postponed) Update(c *Controls) error {
 		return err
 	}
 
-	dictionary, err := c.Ask.RolesDictionary()
+	dictionary, err := c.Ask.RolesDictionary(ctx)
 	if err != nil {
 		return err
 	}
@@ -78,7 +79,7 @@ func (p *Postponed) Schedule() schedule.Schedule {
 }
 
 // add post to vk & cache
-func (p *Postponed) AddPost(c *Controls, params vk.PostParams) error {
+func (p *Postponed) AddPost(ctx context.Context, c *Controls, params vk.PostParams) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -87,7 +88,7 @@ func (p *Postponed) AddPost(c *Controls, params vk.PostParams) error {
 		return err
 	}
 
-	dictionary, err := c.Ask.RolesDictionary()
+	dictionary, err := c.Ask.RolesDictionary(ctx)
 	if err != nil {
 		return err
 	}
@@ -121,11 +122,11 @@ func (p *Postponed) DeletePost(c *Controls, post posts.Post) error {
 	return nil
 }
 
-func (p *Postponed) AddPosts(c *Controls, params []vk.PostParams) error {
+func (p *Postponed) AddPosts(ctx context.Context, c *Controls, params []vk.PostParams) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	dictionary, err := c.Ask.RolesDictionary()
+	dictionary, err := c.Ask.RolesDictionary(ctx)
 	if err != nil {
 		return err
 	}
@@ -1,14 +1,17 @@
 package watcher
 
 import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
 	"ask-bot/src/vk"
+	"context"
 	"fmt"
 	"time"
 )
 
-func (c *Controls) CheckReservationsDeadline() error {
+func (c *Controls) CheckReservationsDeadline(ctx context.Context) error {
 
-	reservations, err := c.Ask.InProgressReservations()
+	reservations, err := c.Ask.InProgressReservations(ctx)
 	if err != nil {
 		return err
 	}
@@ -21,7 +24,7 @@ func (c *Controls) CheckReservationsDeadline() error {
 		}
 	}
 
-	err = c.Ask.DeleteReservationByDeadline(now)
+	offered, err := c.Ask.DeleteReservationByDeadline(ctx, ask.WatcherActor, now)
 	if err != nil {
 		return err
 	}
@@ -35,6 +38,37 @@ func (c *Controls) CheckReservationsDeadline() error {
 		c.NotifyUser <- message
 	}
 
+	return c.notifyWaitlistOffers(offered)
+
+}
+
+func (c *Controls) RemindReservationsDeadline(ctx context.Context) error {
+	reminders, err := c.Ask.DueReservationReminders(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range reminders {
+		message, err := ts.ParseTemplate(
+			ts.MsgReservationReminder,
+			ts.MsgReservationReminderData{
+				Reservation: reminders[i].Reservation,
+				Hours:       int(reminders[i].Ahead.Hours()),
+			})
+		if err != nil {
+			return err
+		}
+
+		c.NotifyUser <- &vk.MessageParams{
+			Id:   reminders[i].VkID,
+			Text: message,
+		}
+	}
+
 	return nil
+}
 
+// greeting images which failed to download on submit are retried
+func (c *Controls) SaveGreetingImages(ctx context.Context) error {
+	return c.Ask.SaveGreetingImages(ctx)
 }
@@ -1,9 +1,13 @@
 package watcher
 
-import "fmt"
+import (
+	"ask-bot/src/ask"
+	"context"
+	"fmt"
+)
 
-func (c *Controls) CheckAlbums() error {
-	roles, err := c.Ask.Roles()
+func (c *Controls) CheckAlbums(ctx context.Context) error {
+	roles, err := c.Ask.Roles(ctx)
 	if err != nil {
 		return err
 	}
@@ -24,7 +28,7 @@ func (c *Controls) CheckAlbums() error {
 	}
 
 	if len(albums) > 0 {
-		err = c.Ask.ChangeAlbums(albums)
+		err = c.Ask.ChangeAlbums(ctx, ask.WatcherActor, albums)
 		if err != nil {
 			return err
 		}
@@ -33,8 +37,8 @@ func (c *Controls) CheckAlbums() error {
 	return vk_err
 }
 
-func (c *Controls) CheckBoards() error {
-	roles, err := c.Ask.Roles()
+func (c *Controls) CheckBoards(ctx context.Context) error {
+	roles, err := c.Ask.Roles(ctx)
 	if err != nil {
 		return err
 	}
@@ -56,7 +60,7 @@ func (c *Controls) CheckBoards() error {
 	}
 
 	if len(boards) > 0 {
-		err = c.Ask.ChangeBoards(boards)
+		err = c.Ask.ChangeBoards(ctx, ask.WatcherActor, boards)
 		if err != nil {
 			return err
 		}
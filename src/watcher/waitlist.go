@@ -0,0 +1,60 @@
+package watcher
+
+import (
+	"ask-bot/src/ask"
+	ts "ask-bot/src/templates"
+	"ask-bot/src/vk"
+	"context"
+	"time"
+)
+
+func (c *Controls) CheckWaitlist(ctx context.Context) error {
+	expired, err := c.Ask.DeleteExpiredWaitlistOffers(ctx, ask.WatcherActor, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		message, err := ts.ParseTemplate(
+			ts.MsgWaitlistOfferExpired,
+			ts.MsgWaitlistOfferExpiredData{
+				WaitlistEntry: expired[i],
+			})
+		if err != nil {
+			return err
+		}
+
+		c.NotifyUser <- &vk.MessageParams{
+			Id:   expired[i].VkID,
+			Text: message,
+		}
+	}
+
+	// roles of expired offers are offered to next users
+	offered, err := c.Ask.OfferWaitlistRoles(ctx, ask.WatcherActor)
+	if err != nil {
+		return err
+	}
+
+	return c.notifyWaitlistOffers(offered)
+}
+
+func (c *Controls) notifyWaitlistOffers(offered []ask.WaitlistEntry) error {
+	for i := range offered {
+		message, err := ts.ParseTemplate(
+			ts.MsgWaitlistOffer,
+			ts.MsgWaitlistOfferData{
+				WaitlistEntry: offered[i],
+			})
+		if err != nil {
+			return err
+		}
+
+		c.NotifyUser <- &vk.MessageParams{
+			Id:   offered[i].VkID,
+			Text: message,
+		}
+	}
+
+	return nil
+}
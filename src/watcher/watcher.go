@@ -45,13 +45,16 @@ var notifications = struct {
 type Watcher struct {
 	c *Controls
 
+	backup time.Duration
+
 	log *zap.SugaredLogger
 }
 
-func New(controls *Controls, tick time.Duration, log *zap.SugaredLogger) *Watcher {
+func New(controls *Controls, tick time.Duration, backup time.Duration, log *zap.SugaredLogger) *Watcher {
 	return &Watcher{
-		c:   controls,
-		log: log,
+		c:      controls,
+		backup: backup,
+		log:    log,
 	}
 }
 
@@ -64,19 +67,25 @@ func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
 	go w.runWithNotify(ctx, wg, w.c.CheckBoards, notifications.Board)
 
 	go w.run(ctx, wg, w.c.CheckReservationsDeadline)
+	go w.run(ctx, wg, w.c.RemindReservationsDeadline)
+	go w.run(ctx, wg, w.c.CheckWaitlist)
+	go w.runEvery(ctx, wg, w.c.SaveGreetingImages, 10*time.Minute)
 
 	go w.run(ctx, wg, w.c.UpdatePostponed)
 	go w.run(ctx, wg, w.c.DeleteInvalidPostponed)
 
 	go w.run(ctx, wg, w.c.CheckPendingPolls)
 	go w.run(ctx, wg, w.c.CheckOngoingPolls)
+
+	// snapshot is already made by init
+	go w.runEvery(ctx, wg, w.c.BackupDatabase, w.backup)
 }
 
-func (w *Watcher) run(ctx context.Context, wg *sync.WaitGroup, exec func() error) {
+func (w *Watcher) run(ctx context.Context, wg *sync.WaitGroup, exec func(context.Context) error) {
 	wg.Add(1)
 	defer wg.Done()
 
-	err := exec()
+	err := exec(ctx)
 	if err != nil {
 		w.log.Errorw("failed to exec",
 			"error", err)
@@ -87,7 +96,7 @@ func (w *Watcher) run(ctx context.Context, wg *sync.WaitGroup, exec func() error
 	for {
 		select {
 		case <-ticker.C:
-			err := exec()
+			err := exec(ctx)
 			if err != nil {
 				w.log.Errorw("failed to exec",
 					"error", err)
@@ -98,11 +107,11 @@ func (w *Watcher) run(ctx context.Context, wg *sync.WaitGroup, exec func() error
 	}
 }
 
-func (w *Watcher) runWithNotify(ctx context.Context, wg *sync.WaitGroup, exec func() error, notify chan bool) {
+func (w *Watcher) runWithNotify(ctx context.Context, wg *sync.WaitGroup, exec func(context.Context) error, notify chan bool) {
 	wg.Add(1)
 	defer wg.Done()
 
-	err := exec()
+	err := exec(ctx)
 	if err != nil {
 		w.log.Errorw("failed to exec",
 			"error", err)
@@ -113,13 +122,13 @@ func (w *Watcher) runWithNotify(ctx context.Context, wg *sync.WaitGroup, exec fu
 	for {
 		select {
 		case <-ticker.C:
-			err := exec()
+			err := exec(ctx)
 			if err != nil {
 				w.log.Errorw("failed to exec on ticker",
 					"error", err)
 			}
 		case <-notify:
-			err := exec()
+			err := exec(ctx)
 			if err != nil {
 				w.log.Errorw("failed to exec on notify",
 					"error", err)
@@ -129,3 +138,25 @@ func (w *Watcher) runWithNotify(ctx context.Context, wg *sync.WaitGroup, exec fu
 		}
 	}
 }
+
+// first exec is after interval
+func (w *Watcher) runEvery(ctx context.Context, wg *sync.WaitGroup, exec func(context.Context) error, interval time.Duration) {
+	wg.Add(1)
+	defer wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := exec(ctx)
+			if err != nil {
+				w.log.Errorw("failed to exec",
+					"error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}